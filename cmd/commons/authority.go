@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/proto"
+)
+
+// creates or lists health authorities
+func authority(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("authority needs a subcommand: create or list")
+	}
+	flags := newFlagSet("authority " + args[0])
+	var name, id *string
+	var all *bool
+	switch args[0] {
+	case "create":
+		name = flags.String("name", "", "name of the new health authority")
+		id = flags.String("id", "", "hex-encoded authority_id; generated if empty")
+	case "list":
+		all = flags.Bool("all", false, "include disabled health authorities")
+	default:
+		return fmt.Errorf("Unknown authority subcommand %q", args[0])
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if args[0] == "create" {
+		request := &proto.CreateAuthorityRequest{Name: *name}
+		if len(*id) > 0 {
+			if request.AuthorityId, err = parseHexFlag("id", *id); err != nil {
+				return err
+			}
+		}
+		created, api_key, err := db.CreateAuthority(ctx, request)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("authority_id: %x\napi_key:      %x\n", created.AuthorityId, api_key)
+		return nil
+	}
+
+	authorities, err := db.ListAuthorities(ctx, &proto.ListAuthoritiesRequest{IncludeDisabled: *all})
+	if err != nil {
+		return err
+	}
+	for _, listed := range authorities {
+		state := "enabled"
+		if listed.Disabled {
+			state = "disabled"
+		}
+		fmt.Printf("%x  %-8s %2d api keys  %s  %s\n", listed.AuthorityId, state, listed.ActiveApiKeys, listed.CreatedAt, listed.Name)
+	}
+	return nil
+}
+
+// issues one-time authorization keys like GetAuthorizationToken
+func token(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "issue" {
+		return errors.New("token needs a subcommand: issue")
+	}
+	now := time.Now().UTC()
+	flags := newFlagSet("token issue")
+	api_key := flags.String("api-key", "", "hex-encoded api_key of the issuing health authority")
+	start := flags.String("start", now.Add(-14*24*time.Hour).Format(time.RFC3339), "start of the permitted range of ENINs")
+	end := flags.String("end", now.Format(time.RFC3339), "end of the permitted range of ENINs")
+	codeLength := flags.Uint("code-length", 0, "also issue a verification code with this many digits")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	request := &proto.TokenRequest{
+		PermittedRangeStart:    *start,
+		PermittedRangeEnd:      *end,
+		VerificationCodeLength: uint32(*codeLength),
+	}
+	var err error
+	if request.ApiKey, err = parseHexFlag("api-key", *api_key); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	key, err := db.CreateAuthorizationKey(ctx, request)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("authorization_key: %x\nexpires_at:        %s\n", key.Key, key.ExpiresAt.Format(time.RFC3339))
+	if len(key.VerificationCode) > 0 {
+		fmt.Printf("verification_code: %s\ncode_expires_at:   %s\n", key.VerificationCode, key.VerificationCodeExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/export"
+	"github.com/covista/commons/internal/publish"
+)
+
+func keys(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 && args[0] == "export" {
+		return exportKeys(ctx, cfg, args[1:])
+	} else if len(args) > 0 && args[0] == "publish" {
+		return publishKeys(ctx, cfg, args[1:])
+	}
+	return errors.New("keys needs a subcommand: export or publish")
+}
+
+// writes the signed export archives of one day to a directory
+func exportKeys(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := newFlagSet("keys export")
+	authority := flags.String("authority", "", "hex-encoded authority_id to export keys for")
+	day := flags.String("day", time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02"), "UTC day to export")
+	out := flags.String("out", ".", "directory to write the archives to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	authority_id, err := parseHexFlag("authority", *authority)
+	if err != nil {
+		return err
+	}
+	date, err := time.Parse("2006-01-02", *day)
+	if err != nil {
+		return fmt.Errorf("Could not parse -day: %w", err)
+	}
+
+	db, err := openDatabase(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	exporter, err := export.NewFromConfig(db, cfg)
+	if err != nil {
+		return err
+	}
+	batches, err := exporter.ExportDay(ctx, authority_id, date)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		return fmt.Errorf("Could not create %s: %w", *out, err)
+	}
+	for _, batch := range batches {
+		path := filepath.Join(*out, batch.Filename())
+		if err := writeArchive(path, batch); err != nil {
+			return err
+		}
+		fmt.Printf("%s (%d keys)\n", path, len(batch.Keys))
+	}
+	return nil
+}
+
+// publishes the keys uploaded since the previous run to the configured blob store, once
+func publishKeys(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := newFlagSet("keys publish").Parse(args); err != nil {
+		return err
+	}
+	db, err := openDatabase(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	publisher, err := publish.NewFromConfig(db, cfg)
+	if err != nil {
+		return err
+	}
+	result, err := publisher.Publish(ctx)
+	if result != nil && result.Skipped {
+		fmt.Println("Skipped: another replica is publishing")
+	} else if result != nil {
+		fmt.Printf("%d batches (%d keys), %d expired archives pruned\n", result.Batches, result.Keys, result.Pruned)
+	}
+	return err
+}
+
+func writeArchive(path string, batch *export.Batch) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Could not create %s: %w", path, err)
+	}
+	if err := batch.WriteArchive(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("Could not write %s: %w", path, err)
+	}
+	return nil
+}
@@ -1,29 +1,145 @@
 package main
 
 import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
 	"github.com/covista/commons/internal/logging"
-	"github.com/covista/commons/internal/metrics"
-	"github.com/covista/commons/internal/server"
+	"github.com/covista/commons/internal/tracing"
 )
 
-func main() {
-	srv, err := server.NewFromConfig(logging.NewContextWithLogger(), config.NewFromEnv())
+// a subcommand of the commons binary. Every command gets the same configuration, but only
+// the sections it uses are validated, so that operator commands run without the server's
+// settings; args are the arguments following the command name
+type command struct {
+	usage    string
+	sections []config.Section
+	run      func(ctx context.Context, cfg *config.Config, args []string) error
+}
+
+// the sections of an operator command: those needed to open the database, log and trace,
+// and the given ones
+func sections(extra ...config.Section) []config.Section {
+	return append([]config.Section{config.DatabaseSection, config.AuthorizationSection,
+		config.LoggingSection, config.TracingSection}, extra...)
+}
+
+var commands = map[string]command{
+	// nil validates all sections
+	"serve":     {"serve", nil, serve},
+	"migrate":   {"migrate up | down [-steps n] | status", sections(), migrate},
+	"authority": {"authority create -name <name> [-id <hex>] | list [-all]", sections(), authority},
+	"token":     {"token issue -api-key <hex> [-start <RFC3339>] [-end <RFC3339>] [-code-length <n>]", sections(), token},
+	"keys":      {"keys export -authority <hex> [-day <YYYY-MM-DD>] [-out <dir>] | publish", sections(config.ExportSection, config.PublishSection, config.RetentionSection), keys},
+	"purge":     {"purge [-dry-run]", sections(config.RetentionSection), purge},
+}
+
+func usage(flags *flag.FlagSet) {
+	var lines []string
+	for _, cmd := range commands {
+		lines = append(lines, "  commons [flags] "+cmd.usage)
+	}
+	sort.Strings(lines)
+	fmt.Fprintf(os.Stderr, "Usage:\n%s\n\nWithout a command, commons serves. Flags take precedence over COMMONS_* environment\n"+
+		"variables, which take precedence over the config file. Flags:\n", strings.Join(lines, "\n"))
+	flags.PrintDefaults()
+}
+
+// returns a flag set for a (sub)command that prints the command's usage on errors
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", name)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// connects to the configured postgres database. Unless this is a migration, the schema
+// must be up to date
+func openDatabase(ctx context.Context, cfg *config.Config, checkSchema bool) (*database.Database, error) {
+	db, err := database.NewFromConfig(ctx, cfg)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("Could not connect to database: %w", err)
 	}
-	defer srv.Shutdown()
-	go func() {
-		log.Fatal(metrics.Serve())
-	}()
-	go func() {
-		log.Fatal(srv.ServeGRPC())
-	}()
+	if checkSchema {
+		if err := db.CheckSchema(ctx); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// parses a hex-encoded flag value, which is required
+func parseHexFlag(name, value string) ([]byte, error) {
+	if len(value) == 0 {
+		return nil, fmt.Errorf("-%s is required", name)
+	}
+	decoded, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("-%s is not hex-encoded: %w", name, err)
+	}
+	return decoded, nil
+}
+
+// returns a context that is cancelled on SIGINT or SIGTERM. A second signal exits immediately
+func withSignals(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		log.Fatal(srv.ServeHTTP())
+		<-signals
+		cancel()
+		<-signals
+		log.Fatal("Received second signal; exiting")
 	}()
+	return ctx
+}
 
-	<-srv.Done()
+func main() {
+	flags := flag.NewFlagSet("commons", flag.ExitOnError)
+	loader := config.NewLoader(flags)
+	flags.Usage = func() { usage(flags) }
+	flags.Parse(os.Args[1:])
+
+	name, args := "serve", flags.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, found := commands[name]
+	if !found {
+		usage(flags)
+		os.Exit(2)
+	}
+	cfg, err := loader.Load(cmd.sections...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := logging.Configure(cfg); err != nil {
+		log.Fatal(err)
+	}
+	if err := tracing.Configure(cfg); err != nil {
+		log.Fatal(err)
+	}
+	err = cmd.run(withSignals(logging.NewContextWithLogger()), cfg, args)
+	if cerr := tracing.Close(); cerr != nil {
+		log.Println("Could not close trace exporter:", cerr)
+	}
+	if err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2)
+		}
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+)
+
+// applies, reverts or lists schema migrations
+func migrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs a subcommand: up, down or status")
+	}
+	flags := newFlagSet("migrate " + args[0])
+	steps := flags.Int("steps", 1, "number of migrations to revert")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp(ctx)
+		for _, version := range applied {
+			fmt.Printf("applied %d\n", version)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := db.MigrateDown(ctx, *steps)
+		for _, version := range reverted {
+			fmt.Printf("reverted %d\n", version)
+		}
+		return err
+	case "status":
+		statuses, err := db.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-35s %s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	default:
+		return fmt.Errorf("Unknown migrate subcommand %q", args[0])
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/retention"
+)
+
+// runs one retention purge
+func purge(ctx context.Context, cfg *config.Config, args []string) error {
+	flags := newFlagSet("purge")
+	dryRun := flags.Bool("dry-run", cfg.Retention.DryRun, "count what would be deleted without deleting it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg.Retention.DryRun = *dryRun
+
+	db, err := openDatabase(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	job, err := retention.NewFromConfig(db, cfg)
+	if err != nil {
+		return err
+	}
+	result, err := job.Purge(ctx)
+	if err != nil {
+		return err
+	} else if result.Skipped {
+		fmt.Println("another replica is purging; nothing done")
+		return nil
+	}
+	fmt.Printf("reported_keys:      %d\nauthorization_keys: %d\nverification_codes: %d\n",
+		result.ReportedKeys, result.AuthorizationKeys, result.VerificationCodes)
+	return nil
+}
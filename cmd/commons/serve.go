@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/metrics"
+	"github.com/covista/commons/internal/server"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// runs the server until the context is cancelled or a listener fails, then shuts it down
+// gracefully: in-flight calls finish before the metrics listener stops and the store closes
+func serve(ctx context.Context, cfg *config.Config, args []string) error {
+	if err := newFlagSet("serve").Parse(args); err != nil {
+		return err
+	}
+	log := logging.FromContext(ctx)
+	srv, err := server.NewFromConfig(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer srv.Close()
+	metricsServer, err := metrics.NewFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	failed := make(chan error, 7)
+	for _, run := range []func() error{
+		metricsServer.Serve,
+		srv.ServeGRPC,
+		srv.ServeHTTP,
+		srv.RunRetention,
+		srv.RunPublisher,
+		srv.RunHealthChecks,
+		srv.ServeAdmin,
+	} {
+		go func(run func() error) {
+			if err := run(); err != nil {
+				failed <- err
+			}
+		}(run)
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Info("Received signal")
+	case err = <-failed:
+		log.Errorf("Shutting down after error: %s", err)
+	}
+
+	timeout := cfg.Shutdown.Timeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(logging.NewContextWithLogger(), timeout)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	if serr := metricsServer.Shutdown(shutdownCtx); serr != nil {
+		log.Errorf("Could not stop metrics listener: %s", serr)
+	}
+	return err
+}
@@ -1,7 +1,13 @@
+-- Development schema at the latest migration in internal/database/migrations.go, plus a
+-- fake health authority. Production databases are set up and upgraded by the server's
+-- migrations; keep this file in sync when adding one.
 CREATE TABLE IF NOT EXISTS health_authorities (
     authority_id    BYTEA NOT NULL,
     name            TEXT NOT NULL,
     api_key         BYTEA NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT NOW(),
+    disabled        BOOLEAN NOT NULL DEFAULT FALSE,
+    revoked_at      TIMESTAMP,
     UNIQUE(api_key),
     PRIMARY KEY(authority_id, api_key)
 );
@@ -11,18 +17,46 @@ CREATE TABLE IF NOT EXISTS authorization_keys (
     api_key           BYTEA REFERENCES health_authorities(api_key),
     key_type          TEXT,
     permitted_start   TIMESTAMP NOT NULL,
-    permitted_end     TIMESTAMP NOT NULL
+    permitted_end     TIMESTAMP NOT NULL,
+    -- reports can only be added with the key between issued_at and expires_at
+    issued_at         TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at        TIMESTAMP NOT NULL,
+    -- set when the key is used to upload a report; keys can only be redeemed once
+    redeemed_at       TIMESTAMP,
+    redeemed_report   BYTEA
+);
+
+CREATE TABLE IF NOT EXISTS verification_codes (
+    code              TEXT PRIMARY KEY,
+    authorization_key BYTEA NOT NULL REFERENCES authorization_keys(authorization_key),
+    expires_at        TIMESTAMP NOT NULL,
+    exchanged_at      TIMESTAMP,
+    failed_attempts   INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS reported_keys (
-    TEK                BYTEA NOT NULL PRIMARY KEY,
-    ENIN               TIMESTAMP NOT NULL,
-    authorization_key  BYTEA NOT NULL REFERENCES authorization_keys(authorization_key),
-    uploaded_at        TIMESTAMP NOT NULL DEFAULT NOW()
+    TEK                          BYTEA NOT NULL PRIMARY KEY,
+    ENIN                         TIMESTAMP NOT NULL,
+    authorization_key            BYTEA NOT NULL REFERENCES authorization_keys(authorization_key),
+    uploaded_at                  TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- id of the uploading transaction; download cursors are based on this
+    upload_xid                   BIGINT NOT NULL DEFAULT txid_current(),
+    rolling_period               INTEGER NOT NULL DEFAULT 144,
+    transmission_risk_level      INTEGER NOT NULL DEFAULT 0,
+    -- value of the ReportType enum in commons.proto
+    report_type                  INTEGER NOT NULL DEFAULT 0,
+    days_since_onset_of_symptoms INTEGER
 );
 
 CREATE INDEX enin_idx ON reported_keys(ENIN);
 CREATE INDEX hak_idx ON reported_keys(authorization_key);
+CREATE INDEX upload_xid_idx ON reported_keys(upload_xid);
+
+CREATE TABLE IF NOT EXISTS publish_state (
+    authority_id    BYTEA PRIMARY KEY,
+    upload_cursor   TEXT NOT NULL,
+    published_until TIMESTAMP NOT NULL
+);
 
 INSERT INTO health_authorities(authority_id, name, api_key) VALUES (
     decode('da250d7fbffca634bf9b38e9430508bb', 'hex'),
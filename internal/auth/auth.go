@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/covista/commons/internal/certs"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadata key holding the caller's credentials. The HTTP gateway forwards the
+// Authorization header under this key
+const authorizationKey = "authorization"
+
+const bearerPrefix = "Bearer "
+
+// Requirement is the kind of principal an RPC must be called by
+type Requirement int
+
+const (
+	// anyone may call the RPC; credentials are still checked if given
+	Public Requirement = iota
+	// the caller must authenticate as an active health authority
+	HealthAuthority
+)
+
+// Policy maps full GRPC method names (e.g. /proto.DiagnosisDB/AddReport) to the principal
+// they require. Methods missing from the policy are refused
+type Policy map[string]Requirement
+
+// Principal is an authenticated health authority
+type Principal struct {
+	AuthorityID []byte
+	Name        string
+	// the api_key the authority authenticated with, or its newest active one if it only
+	// presented a client certificate
+	ApiKey []byte
+}
+
+type principalKey struct{}
+
+// Attaches the authenticated principal to the context
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Returns the principal the caller authenticated as, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+var (
+	// returned by Store.AuthenticateApiKey when the api_key is unknown, revoked or belongs to
+	// a disabled health authority
+	ErrInvalidApiKey = errors.New("api_key is not valid")
+	// returned when the api_key belongs to another health authority than the one the client
+	// authenticated as
+	ErrWrongAuthority = errors.New("api_key does not belong to the authenticated health authority")
+	// returned by Store.AuthenticateClientAuthority when the health authority of a client
+	// certificate is unknown, disabled or has no active api_key
+	ErrInvalidClientAuthority = errors.New("client certificate does not belong to an active health authority")
+)
+
+// Store looks up the health authorities that credentials belong to
+type Store interface {
+	// returns ErrInvalidApiKey if the api_key does not belong to an active authority
+	AuthenticateApiKey(ctx context.Context, api_key []byte) (*proto.Authority, error)
+	// returns the authority along with its newest active api_key, or
+	// ErrInvalidClientAuthority
+	AuthenticateClientAuthority(ctx context.Context, authority_id []byte) (*proto.Authority, []byte, error)
+}
+
+// Authenticator resolves the caller of each RPC from its credentials and enforces the
+// requirement the Policy declares for the method
+type Authenticator struct {
+	store  Store
+	policy Policy
+}
+
+// Creates a new Authenticator that looks up api keys in the given store
+func NewAuthenticator(store Store, policy Policy) *Authenticator {
+	return &Authenticator{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// Returns interceptors enforcing the policy
+func (a *Authenticator) Interceptors() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, err := a.authenticate(ctx, info.FullMethod, req)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			// the request message is not known yet, so streams only take credentials from metadata
+			ctx, err := a.authenticate(ss.Context(), info.FullMethod, nil)
+			if err != nil {
+				return err
+			}
+			return handler(srv, WrapStream(ss, ctx))
+		}),
+	}
+}
+
+// WrapStream returns the server stream with its context replaced, for stream interceptors
+// that add to the context
+func WrapStream(ss grpc.ServerStream, ctx context.Context) grpc.ServerStream {
+	return &contextStream{ss, ctx}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// requests that still carry the api_key in the message, like TokenRequest
+type apiKeyRequest interface {
+	GetApiKey() []byte
+}
+
+func (a *Authenticator) authenticate(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	requirement, found := a.policy[method]
+	if !found {
+		authFailures.WithLabelValues(method, "undeclared").Inc()
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not available", method)
+	}
+
+	api_key, err := credentials(ctx)
+	if err != nil {
+		authFailures.WithLabelValues(method, "malformed").Inc()
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if r, ok := req.(apiKeyRequest); ok && requirement == HealthAuthority && len(r.GetApiKey()) > 0 {
+		if len(api_key) > 0 && !bytes.Equal(api_key, r.GetApiKey()) {
+			authFailures.WithLabelValues(method, "conflicting").Inc()
+			return nil, status.Error(codes.Unauthenticated, "api_key in the request does not match the credentials")
+		}
+		api_key = r.GetApiKey()
+	}
+
+	if len(api_key) == 0 {
+		// a verified client certificate identifies the authority on its own
+		if client, ok := certs.ClientAuthority(ctx); ok {
+			return a.authenticateClient(ctx, method, client)
+		}
+		if requirement == Public {
+			return ctx, nil
+		}
+		authFailures.WithLabelValues(method, "missing").Inc()
+		return nil, status.Error(codes.Unauthenticated, "Credentials are required")
+	}
+
+	authority, err := a.store.AuthenticateApiKey(ctx, api_key)
+	if errors.Is(err, ErrInvalidApiKey) {
+		authFailures.WithLabelValues(method, "invalid").Inc()
+		return nil, status.Error(codes.Unauthenticated, ErrInvalidApiKey.Error())
+	} else if err != nil {
+		logging.FromContext(ctx).Errorf("Could not authenticate api_key: %s", err)
+		return nil, status.Error(codes.Internal, "Could not authenticate api_key")
+	}
+	if client, ok := certs.ClientAuthority(ctx); ok && !bytes.Equal(client, authority.AuthorityId) {
+		authFailures.WithLabelValues(method, "wrong_authority").Inc()
+		return nil, status.Error(codes.PermissionDenied, ErrWrongAuthority.Error())
+	}
+	return WithPrincipal(ctx, principalOf(authority, api_key)), nil
+}
+
+func (a *Authenticator) authenticateClient(ctx context.Context, method string, authority_id []byte) (context.Context, error) {
+	authority, api_key, err := a.store.AuthenticateClientAuthority(ctx, authority_id)
+	if errors.Is(err, ErrInvalidClientAuthority) {
+		authFailures.WithLabelValues(method, "invalid_certificate").Inc()
+		return nil, status.Error(codes.Unauthenticated, ErrInvalidClientAuthority.Error())
+	} else if err != nil {
+		logging.FromContext(ctx).Errorf("Could not authenticate client certificate: %s", err)
+		return nil, status.Error(codes.Internal, "Could not authenticate client certificate")
+	}
+	return WithPrincipal(ctx, principalOf(authority, api_key)), nil
+}
+
+func principalOf(authority *proto.Authority, api_key []byte) *Principal {
+	return &Principal{
+		AuthorityID: authority.AuthorityId,
+		Name:        authority.Name,
+		ApiKey:      api_key,
+	}
+}
+
+// reads the hex-encoded api_key from an "authorization: Bearer <api_key>" entry. Returns
+// nil if there is none
+func credentials(ctx context.Context) ([]byte, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		return nil, nil
+	} else if len(values) > 1 {
+		return nil, errors.New("Only one authorization header may be given")
+	}
+	if !strings.HasPrefix(values[0], bearerPrefix) {
+		return nil, errors.New("authorization header is not of the form: Bearer <api_key>")
+	}
+	api_key, err := hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(values[0], bearerPrefix)))
+	if err != nil || len(api_key) == 0 {
+		return nil, errors.New("authorization header does not hold a hex-encoded api_key")
+	}
+	return api_key, nil
+}
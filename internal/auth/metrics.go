@@ -0,0 +1,13 @@
+package auth
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	authFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_auth_failures",
+		Help: "Number of calls refused by authentication, by method and reason",
+	}, []string{"method", "reason"})
+)
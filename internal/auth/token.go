@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Returns interceptors refusing every call that does not carry the given token as
+// "authorization: Bearer <token>". Guards services that only operators may call, like Admin
+func TokenInterceptors(token string) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := checkToken(ctx, info.FullMethod, token); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkToken(ss.Context(), info.FullMethod, token); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func checkToken(ctx context.Context, method, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(authorizationKey)
+	if len(values) == 0 {
+		authFailures.WithLabelValues(method, "missing").Inc()
+		return status.Error(codes.Unauthenticated, "Credentials are required")
+	} else if len(values) > 1 || !strings.HasPrefix(values[0], bearerPrefix) {
+		authFailures.WithLabelValues(method, "malformed").Inc()
+		return status.Error(codes.Unauthenticated, "authorization header is not of the form: Bearer <token>")
+	}
+	given := strings.TrimSpace(strings.TrimPrefix(values[0], bearerPrefix))
+	if len(token) == 0 || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		authFailures.WithLabelValues(method, "invalid").Inc()
+		return status.Error(codes.Unauthenticated, "Invalid token")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/covista/commons/internal/config"
+)
+
+// returned by Get for objects that do not exist
+var ErrNotExist = errors.New("Object does not exist")
+
+// Store holds named objects, e.g. in a directory or a bucket that a CDN serves from. Names
+// are slash-separated paths
+type Store interface {
+	// creates or replaces the object; readers never see it partially written
+	Put(ctx context.Context, name string, data []byte, contentType string) error
+	// returns the contents of the object, or ErrNotExist
+	Get(ctx context.Context, name string) ([]byte, error)
+	// removes the object; removing an object that does not exist is not an error
+	Delete(ctx context.Context, name string) error
+}
+
+func checkConfig(cfg *config.Config) error {
+	if cfg != nil && len(cfg.Publish.Backend) == 0 {
+		return errors.New("Publish.Backend is not set")
+	}
+	return config.Check(cfg, config.PublishSection)
+}
+
+// Creates the Store configured for publishing
+func NewFromConfig(cfg *config.Config) (Store, error) {
+	if err := checkConfig(cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config for blob store: %w", err)
+	}
+	if cfg.Publish.Backend == "filesystem" {
+		return NewFilesystem(cfg.Publish.Directory)
+	}
+	return NewS3(cfg.Publish.S3)
+}
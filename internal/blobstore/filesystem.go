@@ -0,0 +1,87 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Filesystem stores objects as files below a directory, e.g. the document root of a web
+// server
+type Filesystem struct {
+	root string
+}
+
+func NewFilesystem(root string) (*Filesystem, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("Could not create %s: %w", root, err)
+	}
+	return &Filesystem{root: root}, nil
+}
+
+func (fs *Filesystem) path(name string) (string, error) {
+	clean := filepath.Clean("/" + name)
+	if clean == "/" || strings.HasSuffix(name, "/") {
+		return "", fmt.Errorf("Invalid object name %q", name)
+	}
+	return filepath.Join(fs.root, filepath.FromSlash(clean)), nil
+}
+
+// Writes the object to a temporary file that is renamed into place
+func (fs *Filesystem) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	path, err := fs.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("Could not create directory for %s: %w", name, err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("Could not create %s: %w", name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("Could not write %s: %w", name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("Could not write %s: %w", name, err)
+	}
+	// temporary files are created private; published objects are meant to be served
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("Could not write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("Could not write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (fs *Filesystem) Get(ctx context.Context, name string) ([]byte, error) {
+	path, err := fs.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotExist
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+func (fs *Filesystem) Delete(ctx context.Context, name string) error {
+	path, err := fs.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Could not delete %s: %w", name, err)
+	}
+	return nil
+}
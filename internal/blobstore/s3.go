@@ -0,0 +1,217 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+)
+
+const (
+	defaultS3Region = "us-east-1"
+	s3Timeout       = time.Minute
+	// the time format of x-amz-date
+	amzDateFormat = "20060102T150405Z"
+)
+
+// S3 stores objects in a bucket of an S3-compatible service such as MinIO. Buckets are
+// addressed path-style, as http(s)://endpoint/bucket/name, and requests are signed with
+// AWS Signature Version 4
+type S3 struct {
+	client          *http.Client
+	endpoint        *url.URL
+	bucket          string
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+func NewS3(cfg config.S3) (*S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse S3 endpoint: %w", err)
+	} else if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("S3 endpoint %q is not an http or https URL", cfg.Endpoint)
+	}
+	region := cfg.Region
+	if len(region) == 0 {
+		region = defaultS3Region
+	}
+	return &S3{
+		client:          &http.Client{Timeout: s3Timeout},
+		endpoint:        endpoint,
+		bucket:          cfg.Bucket,
+		region:          region,
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+	}, nil
+}
+
+func (s *S3) objectURL(name string) *url.URL {
+	path := "/" + s.bucket + "/" + strings.TrimPrefix(name, "/")
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + uriEncode(path, false)
+	return &u
+}
+
+func (s *S3) Put(ctx context.Context, name string, data []byte, contentType string) error {
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(name).String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("Could not create request for %s: %w", name, err)
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(ctx, req, data)
+	if err != nil {
+		return fmt.Errorf("Could not put %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, name string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(name).String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create request for %s: %w", name, err)
+	}
+	resp, err := s.do(ctx, req, nil)
+	if err == ErrNotExist {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not get %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// S3 answers 204 whether or not the object existed; other stores may answer 404
+func (s *S3) Delete(ctx context.Context, name string) error {
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(name).String(), nil)
+	if err != nil {
+		return fmt.Errorf("Could not create request for %s: %w", name, err)
+	}
+	resp, err := s.do(ctx, req, nil)
+	if err == ErrNotExist {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Could not delete %s: %w", name, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// signs and sends the request. Responses other than 2xx are returned as errors
+func (s *S3) do(ctx context.Context, req *http.Request, payload []byte) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	if len(s.accessKeyID) > 0 {
+		s.sign(req, payload, time.Now())
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && (req.Method == http.MethodGet || req.Method == http.MethodDelete) {
+		return nil, ErrNotExist
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("S3 responded %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// adds the AWS Signature Version 4 Authorization header, signing the host and every header
+// of the request
+func (s *S3) sign(req *http.Request, payload []byte, now time.Time) {
+	now = now.UTC()
+	payloadHash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	date := now.Format("20060102")
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(amzDateFormat) + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	for _, part := range []string{s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// percent-encodes everything but unreserved characters, as Signature Version 4 requires.
+// Slashes are kept unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var out strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~', b == '/' && !encodeSlash:
+			out.WriteByte(b)
+		default:
+			fmt.Fprintf(&out, "%%%02X", b)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,213 @@
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/logging"
+)
+
+const defaultReloadInterval = 30 * time.Second
+
+func checkConfig(cfg *config.Config) error {
+	if cfg != nil && (len(cfg.TLS.CertFile) == 0 || len(cfg.TLS.KeyFile) == 0) {
+		return errors.New("TLS.CertFile and TLS.KeyFile are required")
+	}
+	return config.Check(cfg, config.TLSSection)
+}
+
+func clientAuthType(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("TLS.ClientAuth %q is not none, optional or require", mode)
+	}
+}
+
+// Reloader serves the TLS certificate and client CAs from files, picking up changes to
+// the files without a restart. Files are checked at most once per reload interval, when
+// a client connects; if the new files cannot be loaded, the previous ones stay in use
+type Reloader struct {
+	certFile   string
+	keyFile    string
+	caFile     string
+	clientAuth tls.ClientAuthType
+	interval   time.Duration
+	ctx        context.Context
+
+	sync.Mutex
+	config    *tls.Config
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+// Creates a new Reloader for the TLS section of the configuration. Returns nil (and no
+// error) if TLS is not configured
+func NewReloaderFromConfig(ctx context.Context, cfg *config.Config) (*Reloader, error) {
+	if cfg != nil && len(cfg.TLS.CertFile) == 0 && len(cfg.TLS.KeyFile) == 0 {
+		return nil, nil
+	}
+	if err := checkConfig(cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config for TLS: %w", err)
+	}
+	clientAuth, _ := clientAuthType(cfg.TLS.ClientAuth)
+	interval := cfg.TLS.ReloadInterval
+	if interval == 0 {
+		interval = defaultReloadInterval
+	}
+	r := &Reloader{
+		certFile:   cfg.TLS.CertFile,
+		keyFile:    cfg.TLS.KeyFile,
+		caFile:     cfg.TLS.ClientCAFile,
+		clientAuth: clientAuth,
+		interval:   interval,
+		ctx:        ctx,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx).Infof("Loaded TLS certificate from %s", r.certFile)
+	return r, nil
+}
+
+// Returns a TLS configuration for listeners that always uses the current certificate
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.current(), nil
+		},
+		// only consulted by net/http to tell that a certificate is configured
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &r.current().Certificates[0], nil
+		},
+	}
+}
+
+// Returns true if clients may present certificates
+func (r *Reloader) ClientAuth() bool {
+	return r.clientAuth != tls.NoClientCert
+}
+
+func (r *Reloader) current() *tls.Config {
+	r.Lock()
+	defer r.Unlock()
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		if r.changed() {
+			if err := r.loadLocked(); err != nil {
+				tlsReloads.WithLabelValues("failure").Inc()
+				logging.FromContext(r.ctx).Errorf("Could not reload TLS files; keeping the previous ones: %s", err)
+			} else {
+				tlsReloads.WithLabelValues("success").Inc()
+				logging.FromContext(r.ctx).Infof("Reloaded TLS certificate from %s", r.certFile)
+			}
+		}
+	}
+	return r.config
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if len(r.caFile) > 0 {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// must be called with the lock held
+func (r *Reloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) load() error {
+	r.Lock()
+	defer r.Unlock()
+	r.checkedAt = time.Now()
+	return r.loadLocked()
+}
+
+// must be called with the lock held
+func (r *Reloader) loadLocked() error {
+	modTimes := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("Could not stat %s: %w", file, err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("Could not load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		// required by HTTP/2, which GRPC uses
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if len(r.caFile) > 0 {
+		contents, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("Could not read client CA file: %w", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(contents) {
+			return fmt.Errorf("%s does not contain any PEM certificates", r.caFile)
+		}
+	}
+	r.config = config
+	r.modTimes = modTimes
+	return nil
+}
+
+// Returns the authority_id of the health authority a verified client certificate was
+// issued to. The certificate's subject common name must be the hex-encoded authority_id
+func AuthorityFromChains(chains [][]*x509.Certificate) ([]byte, bool) {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil, false
+	}
+	authority_id, err := hex.DecodeString(chains[0][0].Subject.CommonName)
+	if err != nil || len(authority_id) != 16 {
+		return nil, false
+	}
+	return authority_id, true
+}
+
+type clientAuthorityKey struct{}
+
+// Marks the request as coming from a client that authenticated as the given health authority,
+// e.g. with a TLS client certificate. Authorization keys can then only be created with api
+// keys of that authority
+func WithClientAuthority(ctx context.Context, authority_id []byte) context.Context {
+	return context.WithValue(ctx, clientAuthorityKey{}, authority_id)
+}
+
+// Returns the health authority the client authenticated as, if any
+func ClientAuthority(ctx context.Context) ([]byte, bool) {
+	authority_id, ok := ctx.Value(clientAuthorityKey{}).([]byte)
+	return authority_id, ok
+}
@@ -0,0 +1,13 @@
+package certs
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	tlsReloads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_tls_reloads",
+		Help: "Number of times changed TLS files were reloaded",
+	}, []string{"result"})
+)
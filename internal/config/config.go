@@ -1,49 +1,219 @@
 package config
 
 import (
-	"os"
+	"time"
 )
 
 type Config struct {
-	GRPC     GRPC
-	HTTP     HTTP
-	Database Database
+	GRPC          GRPC          `yaml:"grpc"`
+	HTTP          HTTP          `yaml:"http"`
+	TLS           TLS           `yaml:"tls"`
+	Admin         Admin         `yaml:"admin"`
+	Metrics       Metrics       `yaml:"metrics"`
+	RateLimit     RateLimit     `yaml:"rate_limit"`
+	Database      Database      `yaml:"database"`
+	Authorization Authorization `yaml:"authorization"`
+	Export        Export        `yaml:"export"`
+	Signing       Signing       `yaml:"signing"`
+	Retention     Retention     `yaml:"retention"`
+	Shutdown      Shutdown      `yaml:"shutdown"`
+	Logging       Logging       `yaml:"logging"`
+	Tracing       Tracing       `yaml:"tracing"`
+	KeyCache      KeyCache      `yaml:"key_cache"`
+	Publish       Publish       `yaml:"publish"`
 }
 
 type Database struct {
-	Host     string
-	Database string
-	User     string
-	Password string
-	Port     string
+	// "postgres" (the default) or "memory", which keeps everything in process memory
+	Backend  string `yaml:"backend"`
+	Host     string `yaml:"host"`
+	Database string `yaml:"database"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	// file holding the password, e.g. a docker secret; used if Password is empty
+	PasswordFile string `yaml:"password_file"`
+	Port         string `yaml:"port"`
+	// apply pending schema migrations on startup instead of refusing to start
+	Migrate bool `yaml:"migrate"`
 }
 
 type GRPC struct {
-	ListenAddress string
-	Port          string
+	ListenAddress string `yaml:"listen_address"`
+	Port          string `yaml:"port"`
 }
 
 type HTTP struct {
-	ListenAddress string
-	Port          string
+	ListenAddress string `yaml:"listen_address"`
+	Port          string `yaml:"port"`
 }
 
+// TLS for the GRPC and HTTP listeners; leave CertFile empty to serve cleartext
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// PEM bundle of the CAs that issue health authority client certificates
+	ClientCAFile string `yaml:"client_ca_file"`
+	// "none" (the default), "optional" or "require"
+	ClientAuth string `yaml:"client_auth"`
+	// how often the files are checked for changes; 0 uses the default
+	ReloadInterval time.Duration `yaml:"reload_interval"`
+}
+
+// operator-only listener for the Admin service; leave Port empty to disable it
+type Admin struct {
+	// empty listens on 127.0.0.1 only
+	ListenAddress string `yaml:"listen_address"`
+	Port          string `yaml:"port"`
+	// operators authenticate with "authorization: Bearer <token>"; required if Port is set
+	Token string `yaml:"token"`
+	// file holding the token, e.g. a docker secret; used if Token is empty
+	TokenFile string `yaml:"token_file"`
+}
+
+// Prometheus metrics listener
+type Metrics struct {
+	// empty listens on all interfaces
+	ListenAddress string `yaml:"listen_address"`
+	// empty uses the default of 2112
+	Port string `yaml:"port"`
+	// serve /metrics on the HTTP gateway listener instead of a listener of its own
+	OnHTTP bool `yaml:"on_http"`
+}
+
+// token-bucket limits on the RPCs that write to the database
+type RateLimit struct {
+	// per client address
+	Client Limit `yaml:"client"`
+	// per authenticated health authority
+	Authority Limit `yaml:"authority"`
+	// per client address, on ExchangeVerificationCode only. Always enforced; a rate of 0 uses
+	// the default of 1 request every 5 seconds with bursts of 10
+	Verification Limit `yaml:"verification"`
+	// failed ExchangeVerificationCode calls per client address. Once used up, the client cannot
+	// exchange codes at all until it has earned another failure. Always enforced; a rate of 0
+	// uses the default of 1 failure every 10 minutes with bursts of 5
+	VerificationFailures Limit `yaml:"verification_failures"`
+}
+
+type Limit struct {
+	// sustained requests per second; 0 disables the limit
+	Rate float64 `yaml:"rate"`
+	// requests allowed at once on top of the rate; 0 uses the rate rounded up
+	Burst int `yaml:"burst"`
+}
+
+type Authorization struct {
+	// how long a verification code can be exchanged for its authorization key; 0 uses the default
+	VerificationCodeTTL time.Duration `yaml:"verification_code_ttl"`
+	// how long an authorization key can be used to upload a report; 0 uses the default
+	KeyTTL time.Duration `yaml:"key_ttl"`
+	// overrides KeyTTL for individual health authorities, keyed by hex-encoded authority_id
+	AuthorityKeyTTLs map[string]time.Duration `yaml:"authority_key_ttls"`
+	// failed exchanges naming a verification code that it survives before it is burned. A
+	// wrong code only counts against the code it names, if that exists; 0 uses the default of 10
+	MaxVerificationFailures int `yaml:"max_verification_failures"`
+}
+
+type Retention struct {
+	// how long diagnosis and authorization keys, and published archives, are kept; 0 uses the
+	// default of 14 days
+	Period time.Duration `yaml:"period"`
+	// overrides Period for individual health authorities, keyed by hex-encoded authority_id
+	AuthorityPeriods map[string]time.Duration `yaml:"authority_periods"`
+	// how often the purge runs; 0 uses the default
+	Interval time.Duration `yaml:"interval"`
+	// log and count what would be deleted, by the purge or by publishing, without deleting it
+	DryRun bool `yaml:"dry_run"`
+}
+
+type Shutdown struct {
+	// how long in-flight calls and downloads may take to finish on shutdown before they are
+	// cut off; 0 uses the default
+	Timeout time.Duration `yaml:"timeout"`
+	// how long health checks report not serving before the listeners stop, so that load
+	// balancers stop sending new calls first; counts towards Timeout. 0 uses the default
+	DrainDelay time.Duration `yaml:"drain_delay"`
+}
+
+type Logging struct {
+	// "debug", "info" (the default), "warn" or "error"; can be changed at runtime
+	// through the Admin service
+	Level string `yaml:"level"`
+	// "console" (the default) or "json"
+	Format string `yaml:"format"`
+}
+
+type Tracing struct {
+	// where spans are sent: "none" (the default), "stdout" or "file"
+	Exporter string `yaml:"exporter"`
+	// the file the "file" exporter appends spans to, as JSON lines
+	File string `yaml:"file"`
+	// share of traces started by this server that are exported; traces continued from a
+	// client's traceparent follow its sampled flag. 0 uses the default of 1
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// in-memory cache of GetDiagnosisKeys results, for downloads of whole days
+type KeyCache struct {
+	// how long results are served from the cache; 0 disables the cache. Each replica has
+	// its own cache, which only notices reports and purges made through that replica, so
+	// results can lag behind other replicas by up to TTL
+	TTL time.Duration `yaml:"ttl"`
+	// maximum number of cached results; 0 uses the default
+	MaxEntries int `yaml:"max_entries"`
+	// maximum number of diagnosis keys held over all results; 0 uses the default
+	MaxKeys int `yaml:"max_keys"`
+}
+
+// periodic publishing of signed export batches to a blob store, for a CDN to serve
+type Publish struct {
+	// "filesystem" or "s3"; empty disables publishing
+	Backend string `yaml:"backend"`
+	// how often new keys are published; 0 uses the default of 1h
+	Interval time.Duration `yaml:"interval"`
+	// prepended to the names of published objects, e.g. "v1/"
+	Prefix string `yaml:"prefix"`
+	// the directory the filesystem backend writes to
+	Directory string `yaml:"directory"`
+	S3        S3     `yaml:"s3"`
+}
+
+// an S3-compatible service, addressed path-style
+type S3 struct {
+	// e.g. http://localhost:9000 for a local MinIO
+	Endpoint string `yaml:"endpoint"`
+	Bucket   string `yaml:"bucket"`
+	// empty uses us-east-1
+	Region          string `yaml:"region"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+}
+
+type Export struct {
+	// maximum number of keys in a single export file; 0 uses the default
+	MaxKeysPerFile int `yaml:"max_keys_per_file"`
+}
+
+type Signing struct {
+	// every key listed for an authority signs its exports; list a new key alongside
+	// the old one to rotate without downtime
+	Keys []SigningKey `yaml:"keys"`
+}
+
+type SigningKey struct {
+	// hex-encoded authority_id of the health authority this key signs for
+	AuthorityID string `yaml:"authority_id"`
+	// published in the SignatureInfo so clients can pick the verification key
+	KeyID      string `yaml:"key_id"`
+	KeyVersion string `yaml:"key_version"`
+	// PEM-encoded ECDSA P-256 private key
+	PrivateKeyFile string `yaml:"private_key_file"`
+}
+
+// Returns the configuration given by the COMMONS_* environment variables alone. Values
+// that cannot be parsed are left empty; use Loader to have them reported
 func NewFromEnv() *Config {
-	return &Config{
-		GRPC: GRPC{
-			ListenAddress: os.Getenv("COMMONS_GRPC_ADDRESS"),
-			Port:          os.Getenv("COMMONS_GRPC_PORT"),
-		},
-		HTTP: HTTP{
-			ListenAddress: os.Getenv("COMMONS_HTTP_ADDRESS"),
-			Port:          os.Getenv("COMMONS_HTTP_PORT"),
-		},
-		Database: Database{
-			Host:     os.Getenv("COMMONS_DB_HOST"),
-			Database: os.Getenv("COMMONS_DB_DATABASE"),
-			User:     os.Getenv("COMMONS_DB_USER"),
-			Password: os.Getenv("COMMONS_DB_PASSWORD"),
-			Port:     os.Getenv("COMMONS_DB_PORT"),
-		},
-	}
+	cfg := &Config{}
+	applyEnv(cfg)
+	return cfg
 }
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ValidationError lists every problem found while loading a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "Invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Loader assembles a Config from, in increasing order of precedence: a YAML config file,
+// COMMONS_* environment variables, and command-line flags
+type Loader struct {
+	path      string
+	overrides []override
+}
+
+type override struct {
+	setting setting
+	value   string
+}
+
+// a command-line flag recording its value as an override
+type settingFlag struct {
+	loader  *Loader
+	setting setting
+}
+
+func (f *settingFlag) String() string { return "" }
+
+func (f *settingFlag) IsBoolFlag() bool { return f.setting.isBool }
+
+func (f *settingFlag) Set(value string) error {
+	f.loader.overrides = append(f.loader.overrides, override{f.setting, value})
+	return nil
+}
+
+// Creates a new Loader and registers -config, along with a flag for every setting that is
+// not a secret, on the given flag set
+func NewLoader(flags *flag.FlagSet) *Loader {
+	loader := &Loader{}
+	flags.StringVar(&loader.path, "config", os.Getenv("COMMONS_CONFIG"), "YAML config file (env COMMONS_CONFIG)")
+	for _, s := range settings {
+		if s.secret {
+			continue
+		}
+		flags.Var(&settingFlag{loader, s}, s.flagName(), fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	return loader
+}
+
+// Loads the configuration once the flags have been parsed and validates the given sections,
+// or all of them if none are given. If anything is wrong, the error is a *ValidationError
+// listing all problems
+func (l *Loader) Load(sections ...Section) (*Config, error) {
+	cfg := &Config{}
+	if len(l.path) > 0 {
+		if err := loadFile(cfg, l.path); err != nil {
+			return nil, err
+		}
+	}
+
+	problems := applyEnv(cfg)
+	for _, o := range l.overrides {
+		if err := o.setting.set(cfg, o.value); err != nil {
+			problems = append(problems, fmt.Sprintf("-%s: %s", o.setting.flagName(), err))
+		}
+	}
+	if len(cfg.Database.Password) == 0 && len(cfg.Database.PasswordFile) > 0 {
+		password, err := ioutil.ReadFile(cfg.Database.PasswordFile)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Could not read Database.PasswordFile: %s", err))
+		}
+		cfg.Database.Password = strings.TrimRight(string(password), "\r\n")
+	}
+	if len(cfg.Admin.Token) == 0 && len(cfg.Admin.TokenFile) > 0 {
+		token, err := ioutil.ReadFile(cfg.Admin.TokenFile)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Could not read Admin.TokenFile: %s", err))
+		}
+		cfg.Admin.Token = strings.TrimRight(string(token), "\r\n")
+	}
+
+	problems = append(problems, validate(cfg, sections)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// reads a YAML config file. Unknown keys are rejected so that typos don't go unnoticed
+func loadFile(cfg *Config, path string) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(contents, cfg); err != nil {
+		return fmt.Errorf("Could not parse config file %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a setting that can be given as a COMMONS_* environment variable and as a command-line
+// flag. The flag name is derived from the variable, e.g. COMMONS_DB_HOST is -db-host
+type setting struct {
+	env   string
+	usage string
+	set   func(cfg *Config, value string) error
+	// secrets can be given through <env>_FILE, but not as a flag, which would show up in ps
+	secret bool
+	// boolean flags can be given without a value, e.g. -db-migrate
+	isBool bool
+}
+
+var settings = []setting{
+	{env: "COMMONS_GRPC_ADDRESS", usage: "address to serve GRPC on", set: str(func(c *Config) *string { return &c.GRPC.ListenAddress })},
+	{env: "COMMONS_GRPC_PORT", usage: "port to serve GRPC on", set: str(func(c *Config) *string { return &c.GRPC.Port })},
+	{env: "COMMONS_HTTP_ADDRESS", usage: "address to serve the HTTP gateway on", set: str(func(c *Config) *string { return &c.HTTP.ListenAddress })},
+	{env: "COMMONS_HTTP_PORT", usage: "port to serve the HTTP gateway on", set: str(func(c *Config) *string { return &c.HTTP.Port })},
+	{env: "COMMONS_TLS_CERT_FILE", usage: "PEM certificate chain for the GRPC and HTTP listeners", set: str(func(c *Config) *string { return &c.TLS.CertFile })},
+	{env: "COMMONS_TLS_KEY_FILE", usage: "PEM private key for the GRPC and HTTP listeners", set: str(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{env: "COMMONS_TLS_CLIENT_CA_FILE", usage: "PEM bundle of CAs for health authority client certificates", set: str(func(c *Config) *string { return &c.TLS.ClientCAFile })},
+	{env: "COMMONS_TLS_CLIENT_AUTH", usage: `client certificates: "none", "optional" or "require"`, set: str(func(c *Config) *string { return &c.TLS.ClientAuth })},
+	{env: "COMMONS_TLS_RELOAD_INTERVAL", usage: "how often TLS files are checked for changes", set: duration(func(c *Config) *time.Duration { return &c.TLS.ReloadInterval })},
+	{env: "COMMONS_ADMIN_ADDRESS", usage: "address to serve the Admin service on; empty is 127.0.0.1", set: str(func(c *Config) *string { return &c.Admin.ListenAddress })},
+	{env: "COMMONS_ADMIN_PORT", usage: "port to serve the Admin service on; empty disables it", set: str(func(c *Config) *string { return &c.Admin.Port })},
+	{env: "COMMONS_ADMIN_TOKEN", set: str(func(c *Config) *string { return &c.Admin.Token }), secret: true},
+	{env: "COMMONS_METRICS_ADDRESS", usage: "address to serve Prometheus metrics on", set: str(func(c *Config) *string { return &c.Metrics.ListenAddress })},
+	{env: "COMMONS_METRICS_PORT", usage: "port to serve Prometheus metrics on (default 2112)", set: str(func(c *Config) *string { return &c.Metrics.Port })},
+	{env: "COMMONS_METRICS_ON_HTTP", usage: "serve /metrics on the HTTP listener instead of its own", set: boolean(func(c *Config) *bool { return &c.Metrics.OnHTTP }), isBool: true},
+	{env: "COMMONS_RATE_LIMIT_CLIENT_RATE", usage: "requests per second per client address; 0 disables the limit", set: number(func(c *Config) *float64 { return &c.RateLimit.Client.Rate })},
+	{env: "COMMONS_RATE_LIMIT_CLIENT_BURST", usage: "requests per client address allowed at once", set: integer(func(c *Config) *int { return &c.RateLimit.Client.Burst })},
+	{env: "COMMONS_RATE_LIMIT_AUTHORITY_RATE", usage: "requests per second per health authority; 0 disables the limit", set: number(func(c *Config) *float64 { return &c.RateLimit.Authority.Rate })},
+	{env: "COMMONS_RATE_LIMIT_AUTHORITY_BURST", usage: "requests per health authority allowed at once", set: integer(func(c *Config) *int { return &c.RateLimit.Authority.Burst })},
+	{env: "COMMONS_RATE_LIMIT_VERIFICATION_RATE", usage: "verification code exchanges per second per client address; 0 uses the default", set: number(func(c *Config) *float64 { return &c.RateLimit.Verification.Rate })},
+	{env: "COMMONS_RATE_LIMIT_VERIFICATION_BURST", usage: "verification code exchanges per client address allowed at once", set: integer(func(c *Config) *int { return &c.RateLimit.Verification.Burst })},
+	{env: "COMMONS_RATE_LIMIT_VERIFICATION_FAILURES_RATE", usage: "failed verification code exchanges per second per client address; 0 uses the default", set: number(func(c *Config) *float64 { return &c.RateLimit.VerificationFailures.Rate })},
+	{env: "COMMONS_RATE_LIMIT_VERIFICATION_FAILURES_BURST", usage: "failed verification code exchanges per client address allowed at once", set: integer(func(c *Config) *int { return &c.RateLimit.VerificationFailures.Burst })},
+	{env: "COMMONS_DB_BACKEND", usage: `"postgres" or "memory"`, set: str(func(c *Config) *string { return &c.Database.Backend })},
+	{env: "COMMONS_DB_HOST", usage: "postgres host", set: str(func(c *Config) *string { return &c.Database.Host })},
+	{env: "COMMONS_DB_PORT", usage: "postgres port", set: str(func(c *Config) *string { return &c.Database.Port })},
+	{env: "COMMONS_DB_DATABASE", usage: "postgres database name", set: str(func(c *Config) *string { return &c.Database.Database })},
+	{env: "COMMONS_DB_USER", usage: "postgres user", set: str(func(c *Config) *string { return &c.Database.User })},
+	{env: "COMMONS_DB_PASSWORD", set: str(func(c *Config) *string { return &c.Database.Password }), secret: true},
+	{env: "COMMONS_DB_MIGRATE", usage: "apply pending schema migrations on startup", set: boolean(func(c *Config) *bool { return &c.Database.Migrate }), isBool: true},
+	{env: "COMMONS_VERIFICATION_CODE_TTL", usage: "lifetime of verification codes", set: duration(func(c *Config) *time.Duration { return &c.Authorization.VerificationCodeTTL })},
+	{env: "COMMONS_VERIFICATION_MAX_FAILURES", usage: "failed exchanges naming a verification code that it survives before it is burned", set: integer(func(c *Config) *int { return &c.Authorization.MaxVerificationFailures })},
+	{env: "COMMONS_AUTHORIZATION_KEY_TTL", usage: "lifetime of authorization keys", set: duration(func(c *Config) *time.Duration { return &c.Authorization.KeyTTL })},
+	{env: "COMMONS_AUTHORITY_KEY_TTLS", usage: "per-authority authorization key lifetimes as <authority_id>=<duration>,...", set: authorityDurations(func(c *Config) *map[string]time.Duration { return &c.Authorization.AuthorityKeyTTLs })},
+	{env: "COMMONS_EXPORT_MAX_KEYS_PER_FILE", usage: "maximum number of keys per export file", set: integer(func(c *Config) *int { return &c.Export.MaxKeysPerFile })},
+	{env: "COMMONS_SIGNING_KEYS", usage: "export signing keys as <authority_id>:<key_id>:<key_version>:<private_key_file>,...", set: signingKeys},
+	{env: "COMMONS_RETENTION_PERIOD", usage: "how long keys and published archives are kept", set: duration(func(c *Config) *time.Duration { return &c.Retention.Period })},
+	{env: "COMMONS_AUTHORITY_RETENTION_PERIODS", usage: "per-authority retention periods as <authority_id>=<duration>,...", set: authorityDurations(func(c *Config) *map[string]time.Duration { return &c.Retention.AuthorityPeriods })},
+	{env: "COMMONS_RETENTION_INTERVAL", usage: "how often expired keys are purged", set: duration(func(c *Config) *time.Duration { return &c.Retention.Interval })},
+	{env: "COMMONS_RETENTION_DRY_RUN", usage: "count expired keys without deleting them", set: boolean(func(c *Config) *bool { return &c.Retention.DryRun }), isBool: true},
+	{env: "COMMONS_SHUTDOWN_TIMEOUT", usage: "how long in-flight calls may take to finish on shutdown", set: duration(func(c *Config) *time.Duration { return &c.Shutdown.Timeout })},
+	{env: "COMMONS_SHUTDOWN_DRAIN_DELAY", usage: "how long health checks fail on shutdown before the listeners stop", set: duration(func(c *Config) *time.Duration { return &c.Shutdown.DrainDelay })},
+	{env: "COMMONS_LOG_LEVEL", usage: "minimum level of log entries: debug, info, warn or error", set: str(func(c *Config) *string { return &c.Logging.Level })},
+	{env: "COMMONS_LOG_FORMAT", usage: "log encoding: console or json", set: str(func(c *Config) *string { return &c.Logging.Format })},
+	{env: "COMMONS_TRACING_EXPORTER", usage: "where trace spans are sent: none, stdout or file", set: str(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{env: "COMMONS_TRACING_FILE", usage: "file the file exporter appends trace spans to", set: str(func(c *Config) *string { return &c.Tracing.File })},
+	{env: "COMMONS_TRACING_SAMPLE_RATIO", usage: "share of new traces that are exported (default 1)", set: number(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{env: "COMMONS_KEY_CACHE_TTL", usage: "how long downloads are served from the cache; 0 disables it", set: duration(func(c *Config) *time.Duration { return &c.KeyCache.TTL })},
+	{env: "COMMONS_KEY_CACHE_MAX_ENTRIES", usage: "maximum number of cached downloads", set: integer(func(c *Config) *int { return &c.KeyCache.MaxEntries })},
+	{env: "COMMONS_KEY_CACHE_MAX_KEYS", usage: "maximum number of diagnosis keys held in the cache", set: integer(func(c *Config) *int { return &c.KeyCache.MaxKeys })},
+	{env: "COMMONS_PUBLISH_BACKEND", usage: "blob store to publish export batches to: filesystem or s3", set: str(func(c *Config) *string { return &c.Publish.Backend })},
+	{env: "COMMONS_PUBLISH_INTERVAL", usage: "how often new keys are published", set: duration(func(c *Config) *time.Duration { return &c.Publish.Interval })},
+	{env: "COMMONS_PUBLISH_PREFIX", usage: "prefix of the names of published objects", set: str(func(c *Config) *string { return &c.Publish.Prefix })},
+	{env: "COMMONS_PUBLISH_DIRECTORY", usage: "directory the filesystem backend publishes to", set: str(func(c *Config) *string { return &c.Publish.Directory })},
+	{env: "COMMONS_PUBLISH_S3_ENDPOINT", usage: "URL of the S3-compatible service to publish to", set: str(func(c *Config) *string { return &c.Publish.S3.Endpoint })},
+	{env: "COMMONS_PUBLISH_S3_BUCKET", usage: "bucket to publish to", set: str(func(c *Config) *string { return &c.Publish.S3.Bucket })},
+	{env: "COMMONS_PUBLISH_S3_REGION", usage: "region of the bucket (default us-east-1)", set: str(func(c *Config) *string { return &c.Publish.S3.Region })},
+	{env: "COMMONS_PUBLISH_S3_ACCESS_KEY_ID", usage: "access key ID for the S3-compatible service", set: str(func(c *Config) *string { return &c.Publish.S3.AccessKeyID })},
+	{env: "COMMONS_PUBLISH_S3_SECRET_ACCESS_KEY", set: str(func(c *Config) *string { return &c.Publish.S3.SecretAccessKey }), secret: true},
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(s.env, "COMMONS_")), "_", "-")
+}
+
+// returns the value of the environment variable, or the contents of the file named by
+// <name>_FILE. Trailing newlines are stripped from files
+func lookupEnv(name string) (string, bool, error) {
+	value, found := os.LookupEnv(name)
+	path, fromFile := os.LookupEnv(name + "_FILE")
+	if found && fromFile {
+		return "", false, fmt.Errorf("only one of %s and %s_FILE may be set", name, name)
+	} else if !fromFile {
+		return value, found, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("could not read %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(contents), "\r\n"), true, nil
+}
+
+// overrides the configuration with every COMMONS_* variable that is set, and returns the
+// problems with the ones that could not be used
+func applyEnv(cfg *Config) []string {
+	var problems []string
+	for _, s := range settings {
+		value, found, err := lookupEnv(s.env)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if found {
+			if err := s.set(cfg, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", s.env, err))
+			}
+		}
+	}
+	return problems
+}
+
+func str(field func(*Config) *string) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func boolean(field func(*Config) *bool) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*field(cfg) = parsed
+		return nil
+	}
+}
+
+func integer(field func(*Config) *int) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field(cfg) = parsed
+		return nil
+	}
+}
+
+func number(field func(*Config) *float64) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		*field(cfg) = parsed
+		return nil
+	}
+}
+
+func duration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration (e.g. 15m)", value)
+		}
+		*field(cfg) = parsed
+		return nil
+	}
+}
+
+// parses a comma-separated list of <authority_id>=<duration> pairs
+func authorityDurations(field func(*Config) *map[string]time.Duration) func(*Config, string) error {
+	return func(cfg *Config, value string) error {
+		durations := make(map[string]time.Duration)
+		for _, entry := range strings.Split(value, ",") {
+			if len(strings.TrimSpace(entry)) == 0 {
+				continue
+			}
+			parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+			if len(parts) < 2 {
+				return fmt.Errorf("%q is not of the form <authority_id>=<duration>", entry)
+			}
+			parsed, err := time.ParseDuration(parts[1])
+			if err != nil {
+				return fmt.Errorf("%q is not a duration (e.g. 15m)", parts[1])
+			}
+			durations[parts[0]] = parsed
+		}
+		*field(cfg) = durations
+		return nil
+	}
+}
+
+// parses a comma-separated list of signing keys, each of the form
+// <authority_id>:<key_id>:<key_version>:<private_key_file>
+func signingKeys(cfg *Config, value string) error {
+	var keys []SigningKey
+	for _, entry := range strings.Split(value, ",") {
+		if len(strings.TrimSpace(entry)) == 0 {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 4)
+		if len(parts) < 4 {
+			return fmt.Errorf("%q is not of the form <authority_id>:<key_id>:<key_version>:<private_key_file>", entry)
+		}
+		keys = append(keys, SigningKey{
+			AuthorityID:    parts[0],
+			KeyID:          parts[1],
+			KeyVersion:     parts[2],
+			PrivateKeyFile: parts[3],
+		})
+	}
+	cfg.Signing.Keys = keys
+	return nil
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// short tokens could be guessed
+const minAdminTokenLength = 16
+
+// Section is a part of the configuration that is validated as a whole. Commands validate
+// the sections they use, and packages check the sections they are configured from with
+// the same rules
+type Section int
+
+const (
+	// GRPC, HTTP, Admin, Metrics, RateLimit and Shutdown
+	ServerSection Section = iota
+	TLSSection
+	// the Database connection
+	DatabaseSection
+	AuthorizationSection
+	// Export and Signing
+	ExportSection
+	RetentionSection
+	LoggingSection
+	TracingSection
+	KeyCacheSection
+	PublishSection
+)
+
+// every section, in the order problems are reported
+var allSections = []Section{
+	ServerSection, TLSSection, DatabaseSection, AuthorizationSection, ExportSection,
+	RetentionSection, LoggingSection, TracingSection, KeyCacheSection, PublishSection,
+}
+
+// collects the problems found in a configuration
+type checker struct {
+	problems []string
+}
+
+var sectionChecks = map[Section]func(c *checker, cfg *Config){
+	ServerSection:        checkServer,
+	TLSSection:           checkTLS,
+	DatabaseSection:      checkDatabase,
+	AuthorizationSection: checkAuthorization,
+	ExportSection:        checkExport,
+	RetentionSection:     checkRetention,
+	LoggingSection:       checkLogging,
+	TracingSection:       checkTracing,
+	KeyCacheSection:      checkKeyCache,
+	PublishSection:       checkPublish,
+}
+
+// Validate checks the given sections of the configuration, or all of them if none are
+// given, and returns a *ValidationError listing every problem, or nil
+func Validate(cfg *Config, sections ...Section) error {
+	if problems := validate(cfg, sections); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Check is like Validate, but returns a plain error, for packages checking the sections
+// they are configured from
+func Check(cfg *Config, sections ...Section) error {
+	if cfg == nil {
+		return errors.New("Configuration is nil")
+	}
+	if problems := validate(cfg, sections); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func validate(cfg *Config, sections []Section) []string {
+	if len(sections) == 0 {
+		sections = allSections
+	}
+	c := &checker{}
+	for _, section := range sections {
+		sectionChecks[section](c, cfg)
+	}
+	return c.problems
+}
+
+func (c *checker) report(format string, args ...interface{}) {
+	c.problems = append(c.problems, fmt.Sprintf(format, args...))
+}
+
+func (c *checker) port(name, port string) {
+	if value, err := strconv.Atoi(port); err != nil || value <= 0 || value > 65535 {
+		c.report("%s %q is not a valid port", name, port)
+	}
+}
+
+func (c *checker) authorityDurations(name string, durations map[string]time.Duration) {
+	for authority_id, duration := range durations {
+		if _, err := hex.DecodeString(authority_id); err != nil || len(authority_id) == 0 {
+			c.report("%s key %q is not a hex-encoded authority_id", name, authority_id)
+		} else if duration <= 0 {
+			c.report("%s[%s] is not a positive duration", name, authority_id)
+		}
+	}
+}
+
+func (c *checker) limit(name string, limit Limit) {
+	if limit.Rate < 0 {
+		c.report("%s.Rate is negative", name)
+	}
+	if limit.Burst < 0 {
+		c.report("%s.Burst is negative", name)
+	}
+}
+
+func checkServer(c *checker, cfg *Config) {
+	if len(cfg.GRPC.ListenAddress) == 0 {
+		c.report("GRPC.ListenAddress is empty")
+	}
+	c.port("GRPC.Port", cfg.GRPC.Port)
+	if len(cfg.HTTP.ListenAddress) == 0 {
+		c.report("HTTP.ListenAddress is empty")
+	}
+	c.port("HTTP.Port", cfg.HTTP.Port)
+	if len(cfg.Admin.Port) > 0 {
+		c.port("Admin.Port", cfg.Admin.Port)
+		if len(cfg.Admin.Token) == 0 {
+			c.report("Admin.Token is required when Admin.Port is set")
+		} else if len(cfg.Admin.Token) < minAdminTokenLength {
+			c.report("Admin.Token is shorter than %d characters", minAdminTokenLength)
+		}
+	}
+	if len(cfg.Metrics.Port) > 0 {
+		c.port("Metrics.Port", cfg.Metrics.Port)
+	}
+	c.limit("RateLimit.Client", cfg.RateLimit.Client)
+	c.limit("RateLimit.Authority", cfg.RateLimit.Authority)
+	c.limit("RateLimit.Verification", cfg.RateLimit.Verification)
+	c.limit("RateLimit.VerificationFailures", cfg.RateLimit.VerificationFailures)
+	if cfg.Shutdown.Timeout < 0 {
+		c.report("Shutdown.Timeout is negative")
+	}
+	if cfg.Shutdown.DrainDelay < 0 {
+		c.report("Shutdown.DrainDelay is negative")
+	}
+}
+
+func checkTLS(c *checker, cfg *Config) {
+	if len(cfg.TLS.CertFile) == 0 && len(cfg.TLS.KeyFile) > 0 {
+		c.report("TLS.KeyFile is set without TLS.CertFile")
+	} else if len(cfg.TLS.CertFile) > 0 && len(cfg.TLS.KeyFile) == 0 {
+		c.report("TLS.CertFile is set without TLS.KeyFile")
+	}
+	switch cfg.TLS.ClientAuth {
+	case "", "none":
+	case "optional", "require":
+		if len(cfg.TLS.CertFile) == 0 {
+			c.report("TLS.ClientAuth %q requires TLS.CertFile", cfg.TLS.ClientAuth)
+		}
+		if len(cfg.TLS.ClientCAFile) == 0 {
+			c.report("TLS.ClientAuth %q requires TLS.ClientCAFile", cfg.TLS.ClientAuth)
+		}
+	default:
+		c.report("TLS.ClientAuth %q is not none, optional or require", cfg.TLS.ClientAuth)
+	}
+	if cfg.TLS.ReloadInterval < 0 {
+		c.report("TLS.ReloadInterval is negative")
+	}
+}
+
+func checkDatabase(c *checker, cfg *Config) {
+	switch cfg.Database.Backend {
+	case "", "postgres":
+		if len(cfg.Database.Host) == 0 {
+			c.report("Database.Host is empty")
+		}
+		if len(cfg.Database.Database) == 0 {
+			c.report("Database.Database is empty")
+		}
+		if len(cfg.Database.User) == 0 {
+			c.report("Database.User is empty")
+		}
+		if len(cfg.Database.Password) == 0 {
+			c.report("Database.Password is empty")
+		}
+		c.port("Database.Port", cfg.Database.Port)
+	case "memory":
+	default:
+		c.report("Database.Backend %q is not postgres or memory", cfg.Database.Backend)
+	}
+}
+
+func checkAuthorization(c *checker, cfg *Config) {
+	if cfg.Authorization.KeyTTL < 0 {
+		c.report("Authorization.KeyTTL is negative")
+	}
+	if cfg.Authorization.VerificationCodeTTL < 0 {
+		c.report("Authorization.VerificationCodeTTL is negative")
+	}
+	if cfg.Authorization.MaxVerificationFailures < 0 {
+		c.report("Authorization.MaxVerificationFailures is negative")
+	}
+	c.authorityDurations("Authorization.AuthorityKeyTTLs", cfg.Authorization.AuthorityKeyTTLs)
+}
+
+func checkExport(c *checker, cfg *Config) {
+	if cfg.Export.MaxKeysPerFile < 0 {
+		c.report("Export.MaxKeysPerFile is negative")
+	}
+	for idx, key := range cfg.Signing.Keys {
+		if id, err := hex.DecodeString(key.AuthorityID); err != nil || len(id) != 16 {
+			c.report("Signing.Keys[%d].AuthorityID %q of %s is not a hex-encoded 16-byte authority_id", idx, key.AuthorityID, key.PrivateKeyFile)
+		}
+		if len(key.KeyID) == 0 {
+			c.report("Signing.Keys[%d].KeyID is empty", idx)
+		}
+		if len(key.KeyVersion) == 0 {
+			c.report("Signing.Keys[%d].KeyVersion is empty", idx)
+		}
+		if len(key.PrivateKeyFile) == 0 {
+			c.report("Signing.Keys[%d].PrivateKeyFile is empty", idx)
+		}
+	}
+}
+
+func checkRetention(c *checker, cfg *Config) {
+	if cfg.Retention.Period < 0 {
+		c.report("Retention.Period is negative")
+	}
+	if cfg.Retention.Interval < 0 {
+		c.report("Retention.Interval is negative")
+	}
+	c.authorityDurations("Retention.AuthorityPeriods", cfg.Retention.AuthorityPeriods)
+}
+
+func checkLogging(c *checker, cfg *Config) {
+	switch cfg.Logging.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		c.report("Logging.Level %q is not debug, info, warn or error", cfg.Logging.Level)
+	}
+	switch cfg.Logging.Format {
+	case "", "console", "json":
+	default:
+		c.report("Logging.Format %q is not console or json", cfg.Logging.Format)
+	}
+}
+
+func checkTracing(c *checker, cfg *Config) {
+	switch cfg.Tracing.Exporter {
+	case "", "none", "stdout":
+	case "file":
+		if len(cfg.Tracing.File) == 0 {
+			c.report("Tracing.File is required for the file exporter")
+		}
+	default:
+		c.report("Tracing.Exporter %q is not none, stdout or file", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		c.report("Tracing.SampleRatio %v is not between 0 and 1", cfg.Tracing.SampleRatio)
+	}
+}
+
+func checkKeyCache(c *checker, cfg *Config) {
+	if cfg.KeyCache.TTL < 0 {
+		c.report("KeyCache.TTL is negative")
+	}
+	if cfg.KeyCache.MaxEntries < 0 {
+		c.report("KeyCache.MaxEntries is negative")
+	}
+	if cfg.KeyCache.MaxKeys < 0 {
+		c.report("KeyCache.MaxKeys is negative")
+	}
+}
+
+func checkPublish(c *checker, cfg *Config) {
+	switch cfg.Publish.Backend {
+	case "":
+	case "filesystem":
+		if len(cfg.Publish.Directory) == 0 {
+			c.report("Publish.Directory is required for the filesystem backend")
+		}
+	case "s3":
+		if len(cfg.Publish.S3.Endpoint) == 0 {
+			c.report("Publish.S3.Endpoint is required for the s3 backend")
+		}
+		if len(cfg.Publish.S3.Bucket) == 0 {
+			c.report("Publish.S3.Bucket is required for the s3 backend")
+		}
+	default:
+		c.report("Publish.Backend %q is not filesystem or s3", cfg.Publish.Backend)
+	}
+	if cfg.Publish.Interval < 0 {
+		c.report("Publish.Interval is negative")
+	}
+}
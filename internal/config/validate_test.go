@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateSections(t *testing.T) {
+	// valid but for the server settings
+	cfg := &Config{
+		Database: Database{Backend: "memory"},
+		Admin:    Admin{Port: "5002", Token: "short"},
+	}
+	for _, tt := range []struct {
+		name     string
+		sections []Section
+		problems int
+	}{
+		{"operator command", []Section{DatabaseSection, AuthorizationSection, LoggingSection, TracingSection}, 0},
+		{"server", []Section{ServerSection}, 5},
+		{"all sections", nil, 5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(cfg, tt.sections...)
+			var invalid *ValidationError
+			if tt.problems == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			} else if !errors.As(err, &invalid) || len(invalid.Problems) != tt.problems {
+				t.Fatalf("expected %d problems, got %v", tt.problems, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		cfg     *Config
+		section Section
+		valid   bool
+	}{
+		{"nil", nil, LoggingSection, false},
+		{"default logging", &Config{}, LoggingSection, true},
+		{"unknown level", &Config{Logging: Logging{Level: "verbose"}}, LoggingSection, false},
+		{"client auth without CA", &Config{TLS: TLS{CertFile: "c", KeyFile: "k", ClientAuth: "require"}}, TLSSection, false},
+		{"filesystem without directory", &Config{Publish: Publish{Backend: "filesystem"}}, PublishSection, false},
+		{"negative cache TTL", &Config{KeyCache: KeyCache{TTL: -1}}, KeyCacheSection, false},
+		{"negative drain delay", &Config{GRPC: GRPC{ListenAddress: "a", Port: "1"}, HTTP: HTTP{ListenAddress: "a", Port: "2"}, Shutdown: Shutdown{DrainDelay: -1}}, ServerSection, false},
+		{"server", &Config{GRPC: GRPC{ListenAddress: "a", Port: "1"}, HTTP: HTTP{ListenAddress: "a", Port: "2"}}, ServerSection, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(tt.cfg, tt.section); (err == nil) != tt.valid {
+				t.Fatalf("expected valid %v, got %v", tt.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/proto"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// Each row of health_authorities holds one api_key of an authority, so the authority
+// itself is the aggregate of all rows sharing an authority_id
+const selectAuthorities = `SELECT authority_id, name, bool_or(disabled), count(*) FILTER (WHERE revoked_at IS NULL),
+						   min(created_at) FROM health_authorities `
+const groupAuthorities = ` GROUP BY authority_id, name`
+
+func scanAuthority(row pgx.Row) (*proto.Authority, error) {
+	var (
+		authority  proto.Authority
+		active     int64
+		created_at time.Time
+	)
+	if err := row.Scan(&authority.AuthorityId, &authority.Name, &authority.Disabled, &active, &created_at); err != nil {
+		return nil, err
+	}
+	authority.ActiveApiKeys = uint32(active)
+	authority.CreatedAt = created_at.UTC().Format(time.RFC3339)
+	return &authority, nil
+}
+
+func getAuthority(ctx context.Context, txn pgx.Tx, authority_id []byte) (*proto.Authority, error) {
+	authority, err := scanAuthority(txn.QueryRow(ctx, selectAuthorities+`WHERE authority_id = $1`+groupAuthorities, authority_id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, newKindError(ErrNotFound, "No health authority with authority_id %x", authority_id)
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not look up health authority: %w", err)
+	}
+	return authority, nil
+}
+
+func newApiKey() ([]byte, error) {
+	api_key, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate api_key: %w", err)
+	}
+	return api_key[:], nil
+}
+
+// Registers a new health authority and returns it along with its first api_key
+func (db *Database) CreateAuthority(ctx context.Context, request *proto.CreateAuthorityRequest) (*proto.Authority, []byte, error) {
+	if err := checkCreateAuthorityRequest(request); err != nil {
+		return nil, nil, fmt.Errorf("Invalid CreateAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	var authority *proto.Authority
+	var api_key []byte
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		authority_id := request.AuthorityId
+		if len(authority_id) == 0 {
+			id, err := uuid.NewRandom()
+			if err != nil {
+				return fmt.Errorf("Could not generate authority_id: %w", err)
+			}
+			authority_id = id[:]
+		}
+
+		var exists bool
+		err := txn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM health_authorities WHERE authority_id = $1)`, authority_id).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("Could not check for existing authority: %w", err)
+		} else if exists {
+			return newKindError(ErrAlreadyExists, "Health authority %x already exists", authority_id)
+		}
+
+		api_key, err = newApiKey()
+		if err != nil {
+			return err
+		}
+		_, err = txn.Exec(ctx, `INSERT INTO health_authorities(authority_id, name, api_key) VALUES ($1, $2, $3)`,
+			authority_id, request.Name, api_key)
+		if err != nil {
+			return fmt.Errorf("Could not insert health authority: %w", err)
+		}
+		log.Infof("Created health authority %s (%x)", request.Name, authority_id)
+
+		authority, err = getAuthority(ctx, txn, authority_id)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return authority, api_key, nil
+}
+
+// Returns all registered health authorities, oldest first
+func (db *Database) ListAuthorities(ctx context.Context, request *proto.ListAuthoritiesRequest) ([]*proto.Authority, error) {
+	var authorities []*proto.Authority
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		query := selectAuthorities + groupAuthorities
+		if !request.GetIncludeDisabled() {
+			query += ` HAVING NOT bool_or(disabled)`
+		}
+		rows, err := txn.Query(ctx, query+` ORDER BY min(created_at)`)
+		if err != nil {
+			return fmt.Errorf("Could not list health authorities: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			authority, err := scanAuthority(rows)
+			if err != nil {
+				return fmt.Errorf("Error reading health authority: %w", err)
+			}
+			authorities = append(authorities, authority)
+		}
+		return rows.Err()
+	})
+	return authorities, err
+}
+
+// Changes the name of a health authority
+func (db *Database) RenameAuthority(ctx context.Context, request *proto.RenameAuthorityRequest) (*proto.Authority, error) {
+	if err := checkRenameAuthorityRequest(request); err != nil {
+		return nil, fmt.Errorf("Invalid RenameAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	var authority *proto.Authority
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		tag, err := txn.Exec(ctx, `UPDATE health_authorities SET name = $2 WHERE authority_id = $1`, request.AuthorityId, request.Name)
+		if err != nil {
+			return fmt.Errorf("Could not rename health authority: %w", err)
+		} else if tag.RowsAffected() == 0 {
+			return newKindError(ErrNotFound, "No health authority with authority_id %x", request.AuthorityId)
+		}
+		log.Infof("Renamed health authority %x to %s", request.AuthorityId, request.Name)
+
+		authority, err = getAuthority(ctx, txn, request.AuthorityId)
+		return err
+	})
+	return authority, err
+}
+
+// Disables (or re-enables) a health authority. Disabled authorities cannot create
+// authorization keys, but keys they already issued remain valid
+func (db *Database) DisableAuthority(ctx context.Context, request *proto.DisableAuthorityRequest) (*proto.Authority, error) {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return nil, fmt.Errorf("Invalid DisableAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	var authority *proto.Authority
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		tag, err := txn.Exec(ctx, `UPDATE health_authorities SET disabled = $2 WHERE authority_id = $1`, request.AuthorityId, !request.Reenable)
+		if err != nil {
+			return fmt.Errorf("Could not update health authority: %w", err)
+		} else if tag.RowsAffected() == 0 {
+			return newKindError(ErrNotFound, "No health authority with authority_id %x", request.AuthorityId)
+		}
+		log.Infof("Set disabled=%t for health authority %x", !request.Reenable, request.AuthorityId)
+
+		authority, err = getAuthority(ctx, txn, request.AuthorityId)
+		return err
+	})
+	return authority, err
+}
+
+// Deletes a health authority and all of its api keys. Authorities that have issued
+// authorization keys are referenced by reported keys and can only be disabled
+func (db *Database) DeleteAuthority(ctx context.Context, request *proto.DeleteAuthorityRequest) error {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return fmt.Errorf("Invalid DeleteAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	return db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		var in_use bool
+		err := txn.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM authorization_keys JOIN health_authorities USING (api_key)
+								  WHERE authority_id = $1)`, request.AuthorityId).Scan(&in_use)
+		if err != nil {
+			return fmt.Errorf("Could not check for issued authorization keys: %w", err)
+		} else if in_use {
+			return newKindError(ErrInUse, "Health authority %x has issued authorization keys; disable it instead", request.AuthorityId)
+		}
+
+		tag, err := txn.Exec(ctx, `DELETE FROM health_authorities WHERE authority_id = $1`, request.AuthorityId)
+		if err != nil {
+			return fmt.Errorf("Could not delete health authority: %w", err)
+		} else if tag.RowsAffected() == 0 {
+			return newKindError(ErrNotFound, "No health authority with authority_id %x", request.AuthorityId)
+		}
+		log.Infof("Deleted health authority %x", request.AuthorityId)
+		return nil
+	})
+}
+
+// Issues an additional api_key for an existing health authority
+func (db *Database) IssueApiKey(ctx context.Context, request *proto.IssueApiKeyRequest) ([]byte, error) {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return nil, fmt.Errorf("Invalid IssueApiKeyRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	var api_key []byte
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		var err error
+		api_key, err = newApiKey()
+		if err != nil {
+			return err
+		}
+		tag, err := txn.Exec(ctx, `INSERT INTO health_authorities(authority_id, name, api_key, disabled)
+								   SELECT authority_id, name, $2, bool_or(disabled) FROM health_authorities
+								   WHERE authority_id = $1 GROUP BY authority_id, name`, request.AuthorityId, api_key)
+		if err != nil {
+			return fmt.Errorf("Could not insert api_key: %w", err)
+		} else if tag.RowsAffected() == 0 {
+			return newKindError(ErrNotFound, "No health authority with authority_id %x", request.AuthorityId)
+		}
+		log.Infof("Issued new api_key for health authority %x", request.AuthorityId)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api_key, nil
+}
+
+// Revokes an api_key. The row is kept because authorization keys issued with it
+// still reference it
+func (db *Database) RevokeApiKey(ctx context.Context, request *proto.RevokeApiKeyRequest) error {
+	if err := checkApiKey(request.GetApiKey()); err != nil {
+		return fmt.Errorf("Invalid RevokeApiKeyRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	return db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		var authority_id []byte
+		err := txn.QueryRow(ctx, `UPDATE health_authorities SET revoked_at = NOW()
+								  WHERE api_key = $1 AND revoked_at IS NULL RETURNING authority_id`, request.ApiKey).Scan(&authority_id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return newKindError(ErrNotFound, "api_key is unknown or already revoked")
+		} else if err != nil {
+			return fmt.Errorf("Could not revoke api_key: %w", err)
+		}
+		log.Infof("Revoked an api_key of health authority %x", authority_id)
+		return nil
+	})
+}
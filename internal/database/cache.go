@@ -0,0 +1,348 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/tracing"
+	"github.com/covista/commons/proto"
+)
+
+const (
+	defaultKeyCacheEntries = 1000
+	defaultKeyCacheKeys    = 1000000
+)
+
+// KeyCache serves repeated GetDiagnosisKeys requests for the same days from memory. Results
+// are dropped when they expire, to make room for others, when AddReport stores keys for
+// one of their days, or when PurgeExpired deletes keys. Downloads with a cursor are always
+// passed on to the store. Only calls through this process are noticed: reports and purges
+// on other replicas show up once results expire
+type KeyCache struct {
+	Store
+	ttl        time.Duration
+	maxEntries int
+	maxKeys    int
+
+	lock sync.Mutex
+	// of *cacheEntry, most recently used first
+	lru     *list.List
+	entries map[string]*list.Element
+	keys    int
+	// results being fetched from the store, so that concurrent misses query it only once
+	fills map[string]*cacheFill
+	// incremented by every invalidation; fills that started before one are not cached
+	generation uint64
+}
+
+type cacheEntry struct {
+	key     string
+	ranges  []eninRange
+	records []*proto.GetDiagnosisKeyResponse
+	expires time.Time
+}
+
+type cacheFill struct {
+	done chan struct{}
+	// nil if the fill failed or its result is stale
+	entry *cacheEntry
+}
+
+// Wraps the store in a KeyCache, or returns it unchanged if the cache is disabled. The
+// returned Store implements RetentionStore if the wrapped one does, but none of its other
+// optional interfaces
+func NewKeyCacheFromConfig(cfg *config.Config, store Store) (Store, error) {
+	if err := config.Check(cfg, config.KeyCacheSection); err != nil {
+		return nil, fmt.Errorf("Invalid config for key cache: %w", err)
+	} else if cfg.KeyCache.TTL == 0 {
+		return store, nil
+	}
+	cache := &KeyCache{
+		Store:      store,
+		ttl:        cfg.KeyCache.TTL,
+		maxEntries: cfg.KeyCache.MaxEntries,
+		maxKeys:    cfg.KeyCache.MaxKeys,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		fills:      make(map[string]*cacheFill),
+	}
+	if cache.maxEntries == 0 {
+		cache.maxEntries = defaultKeyCacheEntries
+	}
+	if cache.maxKeys == 0 {
+		cache.maxKeys = defaultKeyCacheKeys
+	}
+	return cache, nil
+}
+
+// normalizes the request to the authority and the ENIN ranges it selects, so that requests
+// for the same days share an entry however they are phrased
+func cacheKey(request *proto.GetKeyRequest) (string, []eninRange, bool) {
+	if checkGetKeyRequest(request) != nil || len(request.Since) > 0 || request.Resumable {
+		return "", nil, false
+	}
+	ranges, err := requestRanges(request)
+	if err != nil {
+		return "", nil, false
+	}
+	var key strings.Builder
+	key.WriteString(hex.EncodeToString(request.AuthorityId))
+	for _, r := range ranges {
+		fmt.Fprintf(&key, "|%d-%d", r.start.Unix(), r.end.Unix())
+	}
+	return key.String(), ranges, true
+}
+
+func (c *KeyCache) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	span := tracing.SpanFromContext(ctx)
+	key, ranges, ok := cacheKey(request)
+	if !ok {
+		keyCacheLookups.WithLabelValues("uncacheable").Inc()
+		span.SetAttribute("key_cache", "uncacheable")
+		return c.Store.GetDiagnosisKeys(ctx, request)
+	}
+
+	c.lock.Lock()
+	if entry := c.lookup(key); entry != nil {
+		c.lock.Unlock()
+		keyCacheLookups.WithLabelValues("hit").Inc()
+		span.SetAttribute("key_cache", "hit")
+		return replay(ctx, entry.records)
+	}
+	if fill, found := c.fills[key]; found {
+		c.lock.Unlock()
+		select {
+		case <-fill.done:
+		case <-ctx.Done():
+			return failed(ctx.Err())
+		}
+		if fill.entry != nil {
+			keyCacheLookups.WithLabelValues("hit").Inc()
+			span.SetAttribute("key_cache", "hit")
+			return replay(ctx, fill.entry.records)
+		}
+		keyCacheLookups.WithLabelValues("miss").Inc()
+		span.SetAttribute("key_cache", "miss")
+		return c.Store.GetDiagnosisKeys(ctx, request)
+	}
+	fill := &cacheFill{done: make(chan struct{})}
+	c.fills[key] = fill
+	generation := c.generation
+	c.lock.Unlock()
+
+	keyCacheLookups.WithLabelValues("miss").Inc()
+	span.SetAttribute("key_cache", "miss")
+	return c.fill(ctx, request, &cacheEntry{key: key, ranges: ranges}, fill, generation)
+}
+
+// passes the store's results on while collecting them for the cache
+func (c *KeyCache) fill(ctx context.Context, request *proto.GetKeyRequest, entry *cacheEntry, fill *cacheFill, generation uint64) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	key := entry.key
+	results := make(chan *proto.GetDiagnosisKeyResponse)
+	errchan := make(chan error, 1)
+	storeResults, storeErrs := c.Store.GetDiagnosisKeys(ctx, request)
+
+	go func() {
+		collecting := true
+		var err error
+		for storeErrs != nil {
+			select {
+			case resp, ok := <-storeResults:
+				if !ok {
+					storeResults = nil
+					continue
+				}
+				if collecting {
+					entry.records = append(entry.records, resp)
+					if len(entry.records) > c.maxKeys {
+						collecting, entry.records = false, nil
+					}
+				}
+				if err != nil {
+					// keep draining, so that the store can finish
+					continue
+				}
+				select {
+				case results <- resp:
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			case serr, ok := <-storeErrs:
+				if !ok {
+					storeErrs = nil
+				} else if err == nil {
+					err = serr
+				}
+			}
+		}
+		if err != nil || !collecting {
+			entry = nil
+		}
+		c.finishFill(key, fill, entry, generation)
+		if err != nil {
+			errchan <- err
+		} else {
+			close(results)
+		}
+		close(errchan)
+	}()
+	return results, errchan
+}
+
+// caches a successful fill unless an invalidation happened since it started, and hands its
+// result to the requests waiting for it
+func (c *KeyCache) finishFill(key string, fill *cacheFill, entry *cacheEntry, generation uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if entry != nil && generation == c.generation {
+		entry.expires = time.Now().Add(c.ttl)
+		c.add(entry)
+		fill.entry = entry
+	}
+	delete(c.fills, key)
+	close(fill.done)
+}
+
+// Stores the report and drops the cached results for the days of its keys
+func (c *KeyCache) AddReport(ctx context.Context, report *proto.Report) error {
+	if err := c.Store.AddReport(ctx, report); err != nil {
+		return err
+	}
+	c.invalidate(report)
+	return nil
+}
+
+func (c *KeyCache) invalidate(report *proto.Report) {
+	var timestamps []time.Time
+	for _, tek := range report.GetReports() {
+		timestamps = append(timestamps, eninToTimestamp(tek.ENIN))
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		// the report's authority is not known here, so every authority's entries for its
+		// days are dropped
+		if entry := elem.Value.(*cacheEntry); entry.affectedBy(timestamps) {
+			c.remove(elem, "invalidated")
+		}
+		elem = next
+	}
+}
+
+// Purges the store and drops every cached result, since any of them may hold purged keys.
+// Fails if the wrapped store cannot purge
+func (c *KeyCache) PurgeExpired(ctx context.Context, policy RetentionPolicy) (*PurgeResult, error) {
+	purger, ok := c.Store.(RetentionStore)
+	if !ok {
+		return nil, errors.New("Store does not support purging")
+	}
+	result, err := purger.PurgeExpired(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	if !policy.DryRun && !result.Skipped {
+		c.invalidateAll()
+	}
+	return result, nil
+}
+
+func (c *KeyCache) invalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generation++
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Front(), "invalidated")
+	}
+}
+
+// whether a key with one of the timestamps would be part of the entry's result
+func (e *cacheEntry) affectedBy(timestamps []time.Time) bool {
+	for _, ts := range timestamps {
+		matches := true
+		for _, r := range e.ranges {
+			matches = matches && r.contains(ts)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the unexpired entry for the key, or nil. Must be called with the lock held
+func (c *KeyCache) lookup(key string) *cacheEntry {
+	elem, found := c.entries[key]
+	if !found {
+		return nil
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(elem, "expired")
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return entry
+}
+
+// adds the entry, evicting the least recently used ones to stay within the limits. Must be
+// called with the lock held
+func (c *KeyCache) add(entry *cacheEntry) {
+	if len(entry.records) > c.maxKeys {
+		return
+	}
+	if elem, found := c.entries[entry.key]; found {
+		c.remove(elem, "replaced")
+	}
+	for c.lru.Len() > 0 && (c.lru.Len() >= c.maxEntries || c.keys+len(entry.records) > c.maxKeys) {
+		c.remove(c.lru.Back(), "size")
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	c.keys += len(entry.records)
+	keyCacheEntries.Set(float64(c.lru.Len()))
+	keyCacheKeys.Set(float64(c.keys))
+}
+
+// must be called with the lock held
+func (c *KeyCache) remove(elem *list.Element, reason string) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.keys -= len(entry.records)
+	keyCacheEvictions.WithLabelValues(reason).Inc()
+	keyCacheEntries.Set(float64(c.lru.Len()))
+	keyCacheKeys.Set(float64(c.keys))
+}
+
+// streams cached results like the store would
+func replay(ctx context.Context, records []*proto.GetDiagnosisKeyResponse) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	results := make(chan *proto.GetDiagnosisKeyResponse)
+	errchan := make(chan error, 1)
+	go func() {
+		defer close(errchan)
+		for _, record := range records {
+			select {
+			case results <- record:
+			case <-ctx.Done():
+				errchan <- ctx.Err()
+				return
+			}
+		}
+		close(results)
+	}()
+	return results, errchan
+}
+
+func failed(err error) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	errchan := make(chan error, 1)
+	errchan <- err
+	close(errchan)
+	return make(chan *proto.GetDiagnosisKeyResponse), errchan
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/proto"
+)
+
+// counts the downloads that reach the store
+type countingStore struct {
+	*MemoryStore
+	downloads int
+}
+
+func (s *countingStore) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	s.downloads++
+	return s.MemoryStore.GetDiagnosisKeys(ctx, request)
+}
+
+// a KeyCache over a counting MemoryStore that already holds one key, reported on day
+type testCache struct {
+	*KeyCache
+	store   *countingStore
+	api_key []byte
+	day     time.Time
+}
+
+func newTestCache(t *testing.T, ttl time.Duration) *testCache {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.KeyCache.TTL = ttl
+	memory, api_key := newTestStore(t, cfg)
+	store := &countingStore{MemoryStore: memory}
+	cache, err := NewKeyCacheFromConfig(cfg, store)
+	if err != nil {
+		t.Fatalf("could not create key cache: %v", err)
+	}
+	tc := &testCache{
+		KeyCache: cache.(*KeyCache),
+		store:    store,
+		api_key:  api_key,
+		day:      time.Now().UTC().Add(-3 * 24 * time.Hour).Truncate(24 * time.Hour),
+	}
+	addTestReport(t, tc, api_key, 1, tc.day.Add(time.Hour))
+	return tc
+}
+
+// downloads the day's keys through the cache, and checks how many there are and how often
+// the store has been queried in total
+func (tc *testCache) download(t *testing.T, keys, downloads int) {
+	t.Helper()
+	request := &proto.GetKeyRequest{Hrange: &proto.HistoricalRange{StartDate: tc.day.Add(24 * time.Hour).Format(time.RFC3339), Days: 1}}
+	if records, _ := collectKeys(t, tc, request); len(records) != keys {
+		t.Fatalf("expected %d keys, got %d", keys, len(records))
+	}
+	if tc.store.downloads != downloads {
+		t.Fatalf("expected the store to be queried %d times, got %d", downloads, tc.store.downloads)
+	}
+}
+
+func TestKeyCache(t *testing.T) {
+	tc := newTestCache(t, time.Hour)
+	tc.download(t, 1, 1)
+	tc.download(t, 1, 1)
+}
+
+func TestKeyCacheExpiry(t *testing.T) {
+	tc := newTestCache(t, time.Millisecond)
+	tc.download(t, 1, 1)
+	time.Sleep(5 * time.Millisecond)
+	tc.download(t, 1, 2)
+}
+
+func TestKeyCacheInvalidation(t *testing.T) {
+	t.Run("report for the same day", func(t *testing.T) {
+		tc := newTestCache(t, time.Hour)
+		tc.download(t, 1, 1)
+		addTestReport(t, tc, tc.api_key, 2, tc.day.Add(time.Hour))
+		tc.download(t, 2, 2)
+	})
+	t.Run("report for another day", func(t *testing.T) {
+		tc := newTestCache(t, time.Hour)
+		tc.download(t, 1, 1)
+		addTestReport(t, tc, tc.api_key, 2, tc.day.Add(-5*24*time.Hour))
+		tc.download(t, 1, 1)
+	})
+	t.Run("report bypassing the cache", func(t *testing.T) {
+		tc := newTestCache(t, time.Hour)
+		tc.download(t, 1, 1)
+		// as if made through another replica, which the cache cannot know of
+		addTestReport(t, tc.store, tc.api_key, 2, tc.day.Add(time.Hour))
+		tc.download(t, 1, 1)
+	})
+	t.Run("purge", func(t *testing.T) {
+		tc := newTestCache(t, time.Hour)
+		tc.download(t, 1, 1)
+		if _, err := tc.PurgeExpired(context.Background(), RetentionPolicy{Period: time.Hour}); err != nil {
+			t.Fatalf("could not purge: %v", err)
+		}
+		tc.download(t, 0, 2)
+	})
+	t.Run("dry run purge", func(t *testing.T) {
+		tc := newTestCache(t, time.Hour)
+		tc.download(t, 1, 1)
+		if _, err := tc.PurgeExpired(context.Background(), RetentionPolicy{Period: time.Hour, DryRun: true}); err != nil {
+			t.Fatalf("could not purge: %v", err)
+		}
+		tc.download(t, 1, 1)
+	})
+}
+
+// reports a single TEK with a fresh authorization key
+func addTestReport(t *testing.T, store Store, api_key []byte, tek byte, at time.Time) {
+	t.Helper()
+	request := testTokenRequest(api_key, 0)
+	request.PermittedRangeStart = at.Add(-24 * time.Hour).Format(time.RFC3339)
+	key, err := store.CreateAuthorizationKey(context.Background(), request)
+	if err != nil {
+		t.Fatalf("could not create authorization key: %v", err)
+	}
+	if err := store.AddReport(context.Background(), testReport(key.Key, tek, at)); err != nil {
+		t.Fatalf("could not add report: %v", err)
+	}
+}
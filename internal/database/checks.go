@@ -1,81 +1,145 @@
 package database
 
 import (
-	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/covista/commons/internal/config"
 	"github.com/covista/commons/proto"
 )
 
+// the postgres backend also needs the Database section; every Store implementation
+// shares the Authorization section
 func checkConfig(cfg *config.Config) error {
-	if cfg == nil {
-		return errors.New("Configuration is nil")
-	} else if len(cfg.Database.Host) == 0 {
-		return errors.New("Database.Host is empty")
-	} else if len(cfg.Database.Database) == 0 {
-		return errors.New("Database.Database is empty")
-	} else if len(cfg.Database.User) == 0 {
-		return errors.New("Database.User is empty")
-	} else if len(cfg.Database.Password) == 0 {
-		return errors.New("Database.Password is empty")
-	} else if len(cfg.Database.Port) == 0 {
-		return errors.New("Database.Port is empty")
+	return config.Check(cfg, config.DatabaseSection, config.AuthorizationSection)
+}
+
+func checkAuthorityID(authority_id []byte) error {
+	if len(authority_id) == 0 {
+		return invalidField("authority_id", "Empty authority_id")
+	} else if len(authority_id) != 16 {
+		return invalidField("authority_id", "authority_id is not correct length")
+	}
+	return nil
+}
+
+func checkApiKey(api_key []byte) error {
+	if len(api_key) == 0 {
+		return invalidField("api_key", "Empty api_key")
+	} else if len(api_key) != 16 {
+		return invalidField("api_key", "api_key is not correct length")
+	}
+	return nil
+}
+
+func checkCreateAuthorityRequest(req *proto.CreateAuthorityRequest) error {
+	if req == nil {
+		return invalidField("", "Empty CreateAuthorityRequest")
+	} else if len(strings.TrimSpace(req.Name)) == 0 {
+		return invalidField("name", "Empty CreateAuthorityRequest name")
+	} else if len(req.AuthorityId) > 0 && len(req.AuthorityId) != 16 {
+		return invalidField("authority_id", "authority_id is not correct length")
+	}
+	return nil
+}
+
+func checkRenameAuthorityRequest(req *proto.RenameAuthorityRequest) error {
+	if req == nil {
+		return invalidField("", "Empty RenameAuthorityRequest")
+	} else if err := checkAuthorityID(req.AuthorityId); err != nil {
+		return err
+	} else if len(strings.TrimSpace(req.Name)) == 0 {
+		return invalidField("name", "Empty RenameAuthorityRequest name")
 	}
 	return nil
 }
 
 func checkTokenRequest(req *proto.TokenRequest) error {
 	if req == nil {
-		return errors.New("Empty TokenRequest")
+		return invalidField("", "Empty TokenRequest")
 	} else if req.ApiKey == nil {
-		return errors.New("Empty TokenRequest api_key")
+		return invalidField("api_key", "Empty TokenRequest api_key")
 	} else if len(req.ApiKey) != 16 {
-		return errors.New("api_key is not correct length")
+		return invalidField("api_key", "api_key is not correct length")
 	} else if !parsesAsRFC3339(req.PermittedRangeStart) {
-		return errors.New("permitted_range_start is not an RFC3339-formatted timestamp")
+		return invalidField("permitted_range_start", "permitted_range_start is not an RFC3339-formatted timestamp")
 	} else if !parsesAsRFC3339(req.PermittedRangeEnd) {
-		return errors.New("permitted_range_end is not an RFC3339-formatted timestamp")
+		return invalidField("permitted_range_end", "permitted_range_end is not an RFC3339-formatted timestamp")
+	} else if req.VerificationCodeLength != 0 &&
+		(req.VerificationCodeLength < minVerificationCodeLength || req.VerificationCodeLength > maxVerificationCodeLength) {
+		return invalidField("verification_code_length", "verification_code_length must be between %d and %d", minVerificationCodeLength, maxVerificationCodeLength)
 	} else {
 		return nil
 	}
 }
 
+func checkVerificationCodeRequest(req *proto.VerificationCodeRequest) error {
+	if req == nil {
+		return invalidField("", "Empty VerificationCodeRequest")
+	} else if len(req.VerificationCode) < minVerificationCodeLength || len(req.VerificationCode) > maxVerificationCodeLength {
+		return invalidField("verification_code", "verification_code is not correct length")
+	}
+	for _, c := range req.VerificationCode {
+		if c < '0' || c > '9' {
+			return invalidField("verification_code", "verification_code must only contain digits")
+		}
+	}
+	return nil
+}
+
 func checkReport(rep *proto.Report) error {
 	if rep == nil {
-		return errors.New("Empty Report")
+		return invalidField("", "Empty Report")
 	} else if rep.AuthorizationKey == nil {
-		return errors.New("Empty AuthorizationKey")
+		return invalidField("authorization_key", "Empty AuthorizationKey")
 	} else if len(rep.AuthorizationKey) != 16 {
-		return errors.New("authorization_key is not correct length")
+		return invalidField("authorization_key", "authorization_key is not correct length")
 	} else if len(rep.Reports) == 0 {
-		return errors.New("report does not contain any reports")
+		return invalidField("reports", "report does not contain any reports")
 	}
 	for idx, report := range rep.Reports {
 		if err := checkTimestampedTEK(report); err != nil {
-			return fmt.Errorf("Report %d is invalid: %w", idx, err)
+			return err.within(fmt.Sprintf("reports[%d]", idx), "Report %d is invalid", idx)
 		}
 	}
 	return nil
 }
 
-func checkTimestampedTEK(tek *proto.TimestampedTEK) error {
-	if len(tek.TEK) != 16 {
-		return errors.New("TEK was invalid length")
+const (
+	maxRollingPeriod         = 144
+	maxTransmissionRiskLevel = 8
+	maxDaysSinceOnset        = 14
+)
+
+func checkTimestampedTEK(tek *proto.TimestampedTEK) *InvalidFieldError {
+	if tek == nil {
+		return invalidField("", "Empty TimestampedTEK")
+	} else if len(tek.TEK) != 16 {
+		return invalidField("TEK", "TEK was invalid length")
 	} else if tek.ENIN == 0 {
-		return errors.New("ENIN was invalid")
+		return invalidField("ENIN", "ENIN was invalid")
+	} else if tek.RollingPeriod > maxRollingPeriod {
+		return invalidField("rolling_period", "rolling_period must be between 1 and %d", maxRollingPeriod)
+	} else if tek.TransmissionRiskLevel < 0 || tek.TransmissionRiskLevel > maxTransmissionRiskLevel {
+		return invalidField("transmission_risk_level", "transmission_risk_level must be between 0 and %d", maxTransmissionRiskLevel)
+	} else if _, known := proto.ReportType_name[int32(tek.ReportType)]; !known {
+		return invalidField("report_type", "report_type is not a known ReportType")
+	} else if tek.ReportType == proto.ReportType_RECURSIVE || tek.ReportType == proto.ReportType_REVOKED {
+		return invalidField("report_type", "report_type %s cannot be uploaded", tek.ReportType)
+	} else if days := tek.DaysSinceOnsetOfSymptoms; days != nil && (days.Value < -maxDaysSinceOnset || days.Value > maxDaysSinceOnset) {
+		return invalidField("days_since_onset_of_symptoms", "days_since_onset_of_symptoms must be between -%d and %d", maxDaysSinceOnset, maxDaysSinceOnset)
 	}
 	return nil
 }
 
 func checkGetKeyRequest(req *proto.GetKeyRequest) error {
 	if req == nil {
-		return errors.New("Empty query")
-	} else if len(req.AuthorityId) == 0 && req.ENIN == 0 && req.Hrange == nil {
-		return errors.New("GetKeyRequest does not define any filters")
+		return invalidField("", "Empty query")
+	} else if len(req.AuthorityId) == 0 && req.ENIN == 0 && req.Hrange == nil && len(req.Since) == 0 {
+		return invalidField("", "GetKeyRequest does not define any filters")
 	} else if req.Hrange != nil && (len(req.Hrange.StartDate) == 0 && req.Hrange.Days == 0) {
-		return errors.New("GetKeyRequest.historical_range is empty")
+		return invalidField("hrange", "GetKeyRequest.historical_range is empty")
 	}
 	return nil
 }
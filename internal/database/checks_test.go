@@ -0,0 +1,124 @@
+package database
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+// checks that err is nil for valid requests, and otherwise an *InvalidFieldError for field
+func checkInvalidField(t *testing.T, err error, invalid bool, field string) {
+	t.Helper()
+	if !invalid {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	var fieldErr *InvalidFieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("expected an InvalidFieldError for %q, got %v", field, err)
+	} else if fieldErr.Field != field {
+		t.Fatalf("expected field %q, got %q (%s)", field, fieldErr.Field, fieldErr.Description)
+	}
+}
+
+func TestCheckTokenRequest(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	valid := func() *proto.TokenRequest {
+		return &proto.TokenRequest{ApiKey: make([]byte, 16), PermittedRangeStart: now, PermittedRangeEnd: now}
+	}
+	for _, tt := range []struct {
+		name    string
+		request func() *proto.TokenRequest
+		invalid bool
+		field   string
+	}{
+		{"valid", valid, false, ""},
+		{"nil", func() *proto.TokenRequest { return nil }, true, ""},
+		{"missing api_key", func() *proto.TokenRequest { r := valid(); r.ApiKey = nil; return r }, true, "api_key"},
+		{"short api_key", func() *proto.TokenRequest { r := valid(); r.ApiKey = make([]byte, 8); return r }, true, "api_key"},
+		{"bad start", func() *proto.TokenRequest { r := valid(); r.PermittedRangeStart = "2020-05-01"; return r }, true, "permitted_range_start"},
+		{"bad end", func() *proto.TokenRequest { r := valid(); r.PermittedRangeEnd = ""; return r }, true, "permitted_range_end"},
+		{"code too short", func() *proto.TokenRequest { r := valid(); r.VerificationCodeLength = 2; return r }, true, "verification_code_length"},
+		{"code too easy to guess", func() *proto.TokenRequest { r := valid(); r.VerificationCodeLength = 6; return r }, true, "verification_code_length"},
+		{"code too long", func() *proto.TokenRequest { r := valid(); r.VerificationCodeLength = 100; return r }, true, "verification_code_length"},
+		{"code length", func() *proto.TokenRequest { r := valid(); r.VerificationCodeLength = 8; return r }, false, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalidField(t, checkTokenRequest(tt.request()), tt.invalid, tt.field)
+		})
+	}
+}
+
+func TestCheckVerificationCodeRequest(t *testing.T) {
+	for _, tt := range []struct {
+		code    string
+		invalid bool
+	}{
+		{"12345678", false},
+		{"123", true},
+		{"123456", true},
+		{"123456789012345678901234567890", true},
+		{"1234abcd", true},
+		{"", true},
+	} {
+		t.Run(tt.code, func(t *testing.T) {
+			checkInvalidField(t, checkVerificationCodeRequest(&proto.VerificationCodeRequest{VerificationCode: tt.code}), tt.invalid, "verification_code")
+		})
+	}
+}
+
+func TestCheckReport(t *testing.T) {
+	tek := func() *proto.TimestampedTEK {
+		return &proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 2650000, RollingPeriod: 144}
+	}
+	report := func(teks ...*proto.TimestampedTEK) *proto.Report {
+		return &proto.Report{AuthorizationKey: make([]byte, 16), Reports: teks}
+	}
+	for _, tt := range []struct {
+		name   string
+		report *proto.Report
+		field  string
+	}{
+		{"valid", report(tek()), ""},
+		{"missing authorization_key", &proto.Report{Reports: []*proto.TimestampedTEK{tek()}}, "authorization_key"},
+		{"short authorization_key", &proto.Report{AuthorizationKey: make([]byte, 4), Reports: []*proto.TimestampedTEK{tek()}}, "authorization_key"},
+		{"no reports", report(), "reports"},
+		{"nil tek", report(tek(), nil), "reports[1]"},
+		{"short TEK", report(&proto.TimestampedTEK{TEK: make([]byte, 15), ENIN: 1}), "reports[0].TEK"},
+		{"zero ENIN", report(&proto.TimestampedTEK{TEK: make([]byte, 16)}), "reports[0].ENIN"},
+		{"rolling period", report(&proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 1, RollingPeriod: 145}), "reports[0].rolling_period"},
+		{"risk level", report(&proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 1, TransmissionRiskLevel: 9}), "reports[0].transmission_risk_level"},
+		{"unknown report type", report(&proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 1, ReportType: 99}), "reports[0].report_type"},
+		{"revoked report type", report(&proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 1, ReportType: proto.ReportType_REVOKED}), "reports[0].report_type"},
+		{"days since onset", report(&proto.TimestampedTEK{TEK: make([]byte, 16), ENIN: 1, DaysSinceOnsetOfSymptoms: &wrappers.Int32Value{Value: 15}}), "reports[0].days_since_onset_of_symptoms"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalidField(t, checkReport(tt.report), len(tt.field) > 0, tt.field)
+		})
+	}
+}
+
+func TestCheckGetKeyRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		request *proto.GetKeyRequest
+		invalid bool
+		field   string
+	}{
+		{"authority", &proto.GetKeyRequest{AuthorityId: make([]byte, 16)}, false, ""},
+		{"enin", &proto.GetKeyRequest{ENIN: 2650000}, false, ""},
+		{"days", &proto.GetKeyRequest{Hrange: &proto.HistoricalRange{Days: 3}}, false, ""},
+		{"since", &proto.GetKeyRequest{Since: encodeCursor(1)}, false, ""},
+		{"no filters", &proto.GetKeyRequest{}, true, ""},
+		{"empty range", &proto.GetKeyRequest{Hrange: &proto.HistoricalRange{}}, true, "hrange"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			checkInvalidField(t, checkGetKeyRequest(tt.request), tt.invalid, tt.field)
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/covista/commons/internal/certs"
+)
+
+// checks that an api_key of the given authority may be used by the client
+func checkClientAuthority(ctx context.Context, authority_id []byte) error {
+	if client, ok := certs.ClientAuthority(ctx); ok && !bytes.Equal(client, authority_id) {
+		return ErrWrongAuthority
+	}
+	return nil
+}
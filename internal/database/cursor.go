@@ -0,0 +1,36 @@
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Download cursors are opaque to clients. Internally a cursor is the oldest transaction id
+// that was still in progress when a download started (the snapshot xmin). Every key uploaded
+// by an older transaction had already been committed or rolled back, so a download returns
+// only keys uploaded by transactions older than its xmin and hands the xmin out as the next
+// cursor. Keys from newer transactions are held back until the next download, which means
+// no key is skipped or sent twice even when uploads commit out of order.
+const cursorVersion = "v1"
+
+func encodeCursor(xmin int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", cursorVersion, xmin)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, invalidField("since", "since is not a valid cursor")
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || parts[0] != cursorVersion {
+		return 0, invalidField("since", "since is not a valid cursor")
+	}
+	xmin, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || xmin < 0 {
+		return 0, invalidField("since", "since is not a valid cursor")
+	}
+	return xmin, nil
+}
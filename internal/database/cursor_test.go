@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/proto"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, xmin := range []int64{0, 1, 42, 1 << 40, math.MaxInt64} {
+		decoded, err := decodeCursor(encodeCursor(xmin))
+		if err != nil {
+			t.Fatalf("could not decode cursor for %d: %v", xmin, err)
+		} else if decoded != xmin {
+			t.Fatalf("decoded %d, expected %d", decoded, xmin)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for _, tt := range []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "not a cursor!"},
+		{"no version", encode("42")},
+		{"unknown version", encode("v2:42")},
+		{"not a number", encode("v1:abc")},
+		{"negative", encode("v1:-1")},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var invalid *InvalidFieldError
+			if _, err := decodeCursor(tt.cursor); !errors.As(err, &invalid) || invalid.Field != "since" {
+				t.Fatalf("expected since to be invalid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestResumableDownload(t *testing.T) {
+	ctx := context.Background()
+	store, api_key := newTestStore(t, &config.Config{})
+	// the default range ends at midnight
+	at := time.Now().UTC().Add(-24 * time.Hour)
+	report := func(tek byte) {
+		key, err := store.CreateAuthorizationKey(ctx, testTokenRequest(api_key, 0))
+		if err != nil {
+			t.Fatalf("could not create authorization key: %v", err)
+		}
+		if err := store.AddReport(ctx, testReport(key.Key, tek, at)); err != nil {
+			t.Fatalf("could not add report: %v", err)
+		}
+	}
+	// downloads the keys uploaded since the cursor, and checks that they are the given TEKs
+	resume := func(cursor string, teks ...byte) string {
+		t.Helper()
+		records, next := collectKeys(t, store, &proto.GetKeyRequest{Since: cursor})
+		if len(records) != len(teks) {
+			t.Fatalf("expected %d keys, got %d", len(teks), len(records))
+		}
+		for idx, record := range records {
+			if record.TEK[0] != teks[idx] {
+				t.Fatalf("key %d is TEK %d, expected %d", idx, record.TEK[0], teks[idx])
+			}
+		}
+		if len(next) == 0 {
+			t.Fatal("expected a next cursor")
+		}
+		return next
+	}
+
+	report(1)
+	report(2)
+	records, cursor := collectKeys(t, store, &proto.GetKeyRequest{Hrange: &proto.HistoricalRange{Days: 14}, Resumable: true})
+	if len(records) != 2 || len(cursor) == 0 {
+		t.Fatalf("expected 2 keys and a cursor, got %d keys and cursor %q", len(records), cursor)
+	}
+	cursor = resume(cursor)
+	report(3)
+	cursor = resume(cursor, 3)
+	report(4)
+	report(5)
+	cursor = resume(cursor, 4, 5)
+	resume(cursor)
+}
@@ -2,16 +2,20 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"time"
 
 	"github.com/covista/commons/internal/config"
 	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/tracing"
 	"github.com/covista/commons/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func max(a, b uint32) uint32 {
@@ -22,7 +26,23 @@ func max(a, b uint32) uint32 {
 }
 
 func eninToTimestamp(enin uint32) time.Time {
-	return time.Unix(int64(enin*600), 0).UTC()
+	return time.Unix(int64(enin)*600, 0).UTC()
+}
+
+// returns the rolling period of the TEK, defaulting to a full day
+func rollingPeriod(tstek *proto.TimestampedTEK) int32 {
+	if tstek.RollingPeriod == 0 {
+		return maxRollingPeriod
+	}
+	return int32(tstek.RollingPeriod)
+}
+
+// returns the days since onset of symptoms of the TEK, or nil if it is unknown
+func daysSinceOnset(tstek *proto.TimestampedTEK) *int32 {
+	if tstek.DaysSinceOnsetOfSymptoms == nil {
+		return nil
+	}
+	return &tstek.DaysSinceOnsetOfSymptoms.Value
 }
 
 func timestampInRange(ts, start, end time.Time) bool {
@@ -31,7 +51,19 @@ func timestampInRange(ts, start, end time.Time) bool {
 
 // Database object providing pooled connections to the underlying postgres database
 type Database struct {
-	pool *pgxpool.Pool
+	pool      *pgxpool.Pool
+	lifetimes lifetimes
+	collector *poolCollector
+}
+
+// AuthorizationKey is a newly created one-time authorization key
+type AuthorizationKey struct {
+	Key []byte
+	// reports can no longer be added with Key after this time
+	ExpiresAt time.Time
+	// short numeric code that can be exchanged for Key; empty unless requested
+	VerificationCode          string
+	VerificationCodeExpiresAt time.Time
 }
 
 // Creates a new Database instance from the insecure defaults given in the docker compose file.
@@ -63,48 +95,135 @@ func NewFromConfig(ctx context.Context, cfg *config.Config) (*Database, error) {
 	var err error
 	for {
 		pool, err = pgxpool.Connect(ctx, db_connection_url)
-		if err != nil {
-			log.Warnf("Failed to connect to database (%s); retrying in 5 seconds", err.Error())
-			time.Sleep(5 * time.Second)
+		if err == nil {
+			break
+		}
+		log.Warnf("Failed to connect to database (%s); retrying in 5 seconds", err.Error())
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("Gave up connecting to database: %w", ctx.Err())
 		}
-		break
 	}
 	log.Infof("Connected to postgres at %s", cfg.Database.Host)
-	return &Database{
-		pool: pool,
-	}, nil
+	db := &Database{
+		pool:      pool,
+		lifetimes: newLifetimes(cfg),
+		collector: &poolCollector{pool},
+	}
+	if err := prometheus.Register(db.collector); err != nil {
+		log.Warnf("Not exporting database pool metrics: %s", err)
+	}
+	return db, nil
+}
+
+// Returns an error if the database cannot be reached or its schema is not the one this
+// server was built for
+func (db *Database) CheckHealth(ctx context.Context) error {
+	if _, err := db.pool.Exec(ctx, `SELECT 1`); err != nil {
+		return newKindError(ErrUnavailable, "Could not reach database: %w", err)
+	}
+	return db.CheckSchema(ctx)
 }
 
 func (db *Database) Close() {
+	prometheus.Unregister(db.collector)
 	db.pool.Close()
 }
 
-func (db *Database) RunAsTransaction(ctx context.Context, f func(txn pgx.Tx) error) error {
+func (db *Database) RunAsTransaction(ctx context.Context, f func(txn pgx.Tx) error) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "db.Transaction")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	// start transaction in a new pooled connection
-	conn, err := db.pool.Acquire(ctx)
+	acquireCtx, acquireSpan := tracing.StartSpan(ctx, "db.Acquire")
+	conn, err := db.pool.Acquire(acquireCtx)
+	acquireSpan.SetError(err)
+	acquireSpan.End()
 	if err != nil {
-		return fmt.Errorf("Could not acquire connection from pool: %w", err)
+		return newKindError(ErrUnavailable, "Could not acquire connection from pool: %w", err)
 	}
 	defer conn.Release()
 	txn, err := conn.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("Could not begin transaction: %w", err)
+		return newKindError(ErrUnavailable, "Could not begin transaction: %w", err)
 	}
-	if err := f(txn); err != nil {
+	if err := f(&tracedTx{Tx: txn, span: span}); err != nil {
 		if rberr := txn.Rollback(ctx); rberr != nil {
 			return fmt.Errorf("Error (%w) occured during transaction. Could not rollback: %w", err, rberr)
 		}
 		return fmt.Errorf("Error occured during transaction execution: %w", err)
 	}
-	if err := txn.Commit(ctx); err != nil {
+	commitCtx, commitSpan := tracing.StartSpan(ctx, "db.Commit")
+	err = txn.Commit(commitCtx)
+	commitSpan.SetError(err)
+	commitSpan.End()
+	if err != nil {
 		return fmt.Errorf("Error occured during transaction commit: %w", err)
 	}
 	return nil
 }
 
-// Create a one-time use authorization key to be given to a patient.
-func (db *Database) CreateAuthorizationKey(ctx context.Context, request *proto.TokenRequest) ([]byte, error) {
+// Look up the health authority an active api_key belongs to
+func (db *Database) AuthenticateApiKey(ctx context.Context, api_key []byte) (*proto.Authority, error) {
+	if err := checkApiKey(api_key); err != nil {
+		return nil, ErrInvalidApiKey
+	}
+	var authority *proto.Authority
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		var authority_id []byte
+		err := txn.QueryRow(ctx, `SELECT authority_id FROM health_authorities
+								  WHERE api_key=$1 AND revoked_at IS NULL AND NOT disabled`, api_key).Scan(&authority_id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidApiKey
+		} else if err != nil {
+			return fmt.Errorf("Could not look up api_key: %w", err)
+		}
+		authority, err = getAuthority(ctx, txn, authority_id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return authority, nil
+}
+
+// Look up the health authority a verified client certificate belongs to, along with its
+// newest active api_key
+func (db *Database) AuthenticateClientAuthority(ctx context.Context, authority_id []byte) (*proto.Authority, []byte, error) {
+	if err := checkAuthorityID(authority_id); err != nil {
+		return nil, nil, ErrInvalidClientAuthority
+	}
+	var (
+		authority *proto.Authority
+		api_key   []byte
+	)
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		err := txn.QueryRow(ctx, `SELECT api_key FROM health_authorities
+								  WHERE authority_id=$1 AND revoked_at IS NULL AND NOT disabled
+								  ORDER BY created_at DESC LIMIT 1`, authority_id).Scan(&api_key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidClientAuthority
+		} else if err != nil {
+			return fmt.Errorf("Could not look up health authority: %w", err)
+		}
+		authority, err = getAuthority(ctx, txn, authority_id)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return authority, api_key, nil
+}
+
+// Create a one-time use authorization key to be given to a patient. If the request asks for
+// a verification code, one is generated for the key in the same transaction.
+func (db *Database) CreateAuthorizationKey(ctx context.Context, request *proto.TokenRequest) (*AuthorizationKey, error) {
 	var one_time_auth_key uuid.UUID
+	var created AuthorizationKey
 	authKeysCreateAttempts.Inc()
 	// check sanity of tokenrequest
 	if err := checkTokenRequest(request); err != nil {
@@ -119,9 +238,14 @@ func (db *Database) CreateAuthorizationKey(ctx context.Context, request *proto.T
 			authority_id []byte
 			name         string
 		)
-		err := txn.QueryRow(ctx, "SELECT authority_id, name FROM health_authorities where api_key=$1", request.ApiKey).Scan(&authority_id, &name)
-		if err != nil {
-			return fmt.Errorf("Invalid api_key: %w", err)
+		err := txn.QueryRow(ctx, `SELECT authority_id, name FROM health_authorities
+								  WHERE api_key=$1 AND revoked_at IS NULL AND NOT disabled`, request.ApiKey).Scan(&authority_id, &name)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrInvalidApiKey
+		} else if err != nil {
+			return fmt.Errorf("Could not look up api_key: %w", err)
+		} else if err := checkClientAuthority(ctx, authority_id); err != nil {
+			return err
 		}
 		// api_key is valid!
 		log.Infof("Generating one-time auth key for authority %s (%x)", name, authority_id)
@@ -141,19 +265,35 @@ func (db *Database) CreateAuthorizationKey(ctx context.Context, request *proto.T
 			return fmt.Errorf("Could not parse permitted_range_end: %w", err)
 		}
 
+		// the key can only be used for a limited time after it is issued
+		issued_at := time.Now().UTC()
+		created.ExpiresAt = issued_at.Add(db.lifetimes.authorizationKey(authority_id))
+
 		// insert the one-time auth key into the authorization_keys table
 		_, err = txn.Exec(ctx, `INSERT INTO authorization_keys
-					   (authorization_key, api_key, key_type, permitted_start, permitted_end) 
-					   VALUES ($1, $2, $3, $4, $5) ON CONFLICT (authorization_key) DO NOTHING`,
-			one_time_auth_key[:], request.ApiKey, "DIAGNOSED", permitted_start.UTC(), permitted_end.UTC())
+					   (authorization_key, api_key, key_type, permitted_start, permitted_end, issued_at, expires_at) 
+					   VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (authorization_key) DO NOTHING`,
+			one_time_auth_key[:], request.ApiKey, "DIAGNOSED", permitted_start.UTC(), permitted_end.UTC(), issued_at, created.ExpiresAt)
 		if err != nil {
 			return fmt.Errorf("Could not insert new one-time auth key: %w", err)
 		}
+
+		if request.VerificationCodeLength > 0 {
+			created.VerificationCode, created.VerificationCodeExpiresAt, err = createVerificationCode(ctx, txn,
+				one_time_auth_key[:], request.VerificationCodeLength, db.lifetimes.verificationCode(issued_at, created.ExpiresAt))
+			if err != nil {
+				return err
+			}
+		}
 		authKeysCreated.Inc()
 
 		return nil
 	})
-	return one_time_auth_key[:], err
+	if err != nil {
+		return nil, err
+	}
+	created.Key = one_time_auth_key[:]
+	return &created, nil
 }
 
 func (db *Database) AddReport(ctx context.Context, report *proto.Report) error {
@@ -163,14 +303,24 @@ func (db *Database) AddReport(ctx context.Context, report *proto.Report) error {
 	}
 	log := logging.FromContext(ctx)
 
-	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+	report_id, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("Could not generate report id: %w", err)
+	}
+
+	err = db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
 		var permitted_start, permitted_end time.Time
 
-		// validate that authorization_key is valid
+		// validate that authorization_key is valid and redeem it. The row lock taken by the
+		// UPDATE means that concurrent reports with the same key see it as redeemed once
+		// this transaction commits; if this transaction rolls back, the key stays usable
 		log.Infof("New report with auth key %x", report.AuthorizationKey)
-		err := txn.QueryRow(ctx, `SELECT permitted_start, permitted_end FROM authorization_keys
-						   WHERE authorization_key = $1`, report.AuthorizationKey).Scan(&permitted_start, &permitted_end)
-		if err != nil {
+		err := txn.QueryRow(ctx, `UPDATE authorization_keys SET redeemed_at = $3, redeemed_report = $2
+						   WHERE authorization_key = $1 AND redeemed_at IS NULL AND expires_at > $3
+						   RETURNING permitted_start, permitted_end`, report.AuthorizationKey, report_id[:], time.Now().UTC()).Scan(&permitted_start, &permitted_end)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return checkUnredeemedKey(ctx, txn, report.AuthorizationKey)
+		} else if err != nil {
 			return fmt.Errorf("Could not validate authorization key: %w", err)
 		}
 
@@ -178,12 +328,15 @@ func (db *Database) AddReport(ctx context.Context, report *proto.Report) error {
 		for idx, tstek := range report.Reports {
 			timestamp := eninToTimestamp(tstek.ENIN)
 			if !timestampInRange(timestamp, permitted_start, permitted_end) {
-				return fmt.Errorf("Report %d (%s) was not in valid range [%s, %s])", idx, timestamp, permitted_start, permitted_end)
+				return invalidField(fmt.Sprintf("reports[%d].ENIN", idx), "Report %d (%s) was not in valid range [%s, %s])", idx, timestamp, permitted_start, permitted_end)
 			}
 			// insert the TEK, ENIN into the database if it is valid. If there are any errors, this will all be rolled
 			// back and no values from this report will be inserted
-			_, err := txn.Exec(ctx, `INSERT INTO reported_keys(TEK, ENIN, authorization_key)
-									 VALUES($1, $2, $3) ON CONFLICT (TEK) DO NOTHING`, tstek.TEK, timestamp, report.AuthorizationKey)
+			_, err := txn.Exec(ctx, `INSERT INTO reported_keys(TEK, ENIN, authorization_key, rolling_period,
+									 transmission_risk_level, report_type, days_since_onset_of_symptoms)
+									 VALUES($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (TEK) DO NOTHING`,
+				tstek.TEK, timestamp, report.AuthorizationKey, rollingPeriod(tstek), tstek.TransmissionRiskLevel,
+				int32(tstek.ReportType), daysSinceOnset(tstek))
 			if err != nil {
 				return fmt.Errorf("Could not insert report %d into database: %w", idx, err)
 			}
@@ -192,12 +345,41 @@ func (db *Database) AddReport(ctx context.Context, report *proto.Report) error {
 
 		return nil
 	})
+	// surface the reason an authorization key was rejected directly so that clients
+	// do not have to pick it out of a transaction error
+	if errors.Is(err, ErrAuthorizationKeyRedeemed) {
+		addReportRedeemedKey.Inc()
+		return ErrAuthorizationKeyRedeemed
+	} else if errors.Is(err, ErrAuthorizationKeyExpired) {
+		addReportExpiredKey.Inc()
+		return ErrAuthorizationKeyExpired
+	} else if errors.Is(err, ErrUnknownAuthorizationKey) {
+		return ErrUnknownAuthorizationKey
+	}
 	return err
 }
 
-func (db *Database) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.TimestampedTEK, chan error) {
+// called when redeeming an authorization key matched no rows, to tell apart
+// unknown keys from keys that were already used or have expired
+func checkUnredeemedKey(ctx context.Context, txn pgx.Tx, authorization_key []byte) error {
+	var redeemed bool
+	err := txn.QueryRow(ctx, `SELECT redeemed_at IS NOT NULL FROM authorization_keys
+							  WHERE authorization_key = $1`, authorization_key).Scan(&redeemed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrUnknownAuthorizationKey
+	} else if err != nil {
+		return fmt.Errorf("Could not validate authorization key: %w", err)
+	} else if redeemed {
+		return ErrAuthorizationKeyRedeemed
+	}
+	return ErrAuthorizationKeyExpired
+}
+
+// Streams the keys matching the request. Resumable requests end with a message carrying
+// the cursor for the next download instead of a record
+func (db *Database) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.GetDiagnosisKeyResponse, chan error) {
 	getDiagnosisKeysAttempts.Inc()
-	results := make(chan *proto.TimestampedTEK)
+	results := make(chan *proto.GetDiagnosisKeyResponse)
 	errchan := make(chan error, 1)
 
 	if err := checkGetKeyRequest(request); err != nil {
@@ -206,7 +388,7 @@ func (db *Database) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyR
 	}
 
 	log := logging.FromContext(ctx)
-	log.Debugf("Fetching keys for query: health_authority=%x, enin=%d, hrange=%v", request.AuthorityId, request.ENIN, request.Hrange)
+	log.Debugf("Fetching keys for query: health_authority=%x, enin=%d, hrange=%v, since=%s", request.AuthorityId, request.ENIN, request.Hrange, request.Since)
 
 	// construct the SQL query for the provided filter
 	query, values, err := buildQuery(request)
@@ -214,30 +396,67 @@ func (db *Database) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyR
 		errchan <- fmt.Errorf("Could not construct query for GetKeyRequest: %w", err)
 		return results, errchan
 	}
+	resumable := request.Resumable || len(request.Since) > 0
 
 	go func() {
 		err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
 			start := time.Now()
+			var horizon int64
+			if resumable {
+				// taken before the query runs, so every transaction older than the horizon is visible to it
+				if err := txn.QueryRow(ctx, `SELECT txid_snapshot_xmin(txid_current_snapshot())`).Scan(&horizon); err != nil {
+					return fmt.Errorf("Could not determine download cursor: %w", err)
+				}
+				query, values = withUploadHorizon(query, values, horizon)
+			}
 			rows, err := txn.Query(ctx, query, values...)
 			if err != nil {
 				return fmt.Errorf("Could not get reported keys: %w", err)
 			}
 			defer rows.Close()
 			for rows.Next() {
-				var tek []byte
-				var enin time.Time
-				if err := rows.Scan(&tek, &enin); err != nil {
+				var (
+					tek                     []byte
+					enin                    time.Time
+					rolling_period          int32
+					transmission_risk_level int32
+					report_type             int32
+					days_since_onset        *int32
+				)
+				if err := rows.Scan(&tek, &enin, &rolling_period, &transmission_risk_level, &report_type, &days_since_onset); err != nil {
 					return fmt.Errorf("Error getting tek, enin: %w", err)
 				}
-				results <- &proto.TimestampedTEK{
-					TEK:  tek,
-					ENIN: uint32(enin.Unix() / 600),
+				tstek := &proto.TimestampedTEK{
+					TEK:                   tek,
+					ENIN:                  uint32(enin.Unix() / 600),
+					RollingPeriod:         uint32(rolling_period),
+					TransmissionRiskLevel: transmission_risk_level,
+					ReportType:            proto.ReportType(report_type),
+				}
+				if days_since_onset != nil {
+					tstek.DaysSinceOnsetOfSymptoms = &wrappers.Int32Value{Value: *days_since_onset}
+				}
+				// a client that went away must not leave the transaction and its connection open
+				select {
+				case results <- &proto.GetDiagnosisKeyResponse{Record: tstek}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			if err := rows.Err(); err != nil {
+				return err
+			}
+			if resumable {
+				select {
+				case results <- &proto.GetDiagnosisKeyResponse{NextCursor: encodeCursor(horizon)}:
+				case <-ctx.Done():
+					return ctx.Err()
 				}
 			}
 			getDiagnosisKeysSuccess.Inc()
 			getDiagnosisKeysTime.Observe(float64(time.Since(start).Milliseconds()))
 			close(results)
-			return rows.Err()
+			return nil
 		})
 		if err != nil {
 			errchan <- fmt.Errorf("Could not download reported keys: %w", err)
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/covista/commons/internal/auth"
+)
+
+var (
+	// returned by AddReport when the authorization key was already used for a report
+	ErrAuthorizationKeyRedeemed = errors.New("authorization_key has already been redeemed")
+	// returned by AddReport when the authorization key was issued longer ago than its TTL
+	ErrAuthorizationKeyExpired = errors.New("authorization_key has expired")
+	// returned by AddReport when the authorization key does not exist
+	ErrUnknownAuthorizationKey = errors.New("authorization_key is not valid")
+	// returned by ExchangeVerificationCode when the code is unknown, expired or already used
+	ErrInvalidVerificationCode = errors.New("verification_code is invalid or has expired")
+	// returned by AuthenticateApiKey when the api_key is unknown, revoked or belongs to a
+	// disabled health authority
+	ErrInvalidApiKey = auth.ErrInvalidApiKey
+	// returned by CreateAuthorizationKey when the api_key belongs to another health authority
+	// than the one the client authenticated as
+	ErrWrongAuthority = auth.ErrWrongAuthority
+	// returned by AuthenticateClientAuthority when the health authority of a client certificate
+	// is unknown, disabled or has no active api_key
+	ErrInvalidClientAuthority = auth.ErrInvalidClientAuthority
+)
+
+// kinds of errors, for callers to tell them apart with errors.Is. The errors' messages
+// describe the specific problem
+var (
+	// a health authority or api_key does not exist
+	ErrNotFound = errors.New("not found")
+	// a health authority with the same authority_id already exists
+	ErrAlreadyExists = errors.New("already exists")
+	// the health authority is still referenced and cannot be removed
+	ErrInUse = errors.New("in use")
+	// the database could not be reached
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// an error that reads as its own message, but matches its kind with errors.Is
+type kindError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *kindError) Error() string        { return e.msg }
+func (e *kindError) Is(target error) bool { return target == e.kind }
+func (e *kindError) Unwrap() error        { return e.cause }
+
+func newKindError(kind error, format string, args ...interface{}) error {
+	err := fmt.Errorf(format, args...)
+	return &kindError{kind: kind, msg: err.Error(), cause: errors.Unwrap(err)}
+}
+
+// InvalidFieldError is returned when a request fails validation. Field is the name of the
+// offending field, or empty if the request as a whole is missing or incomplete
+type InvalidFieldError struct {
+	Field       string
+	Description string
+}
+
+func (e *InvalidFieldError) Error() string {
+	return e.Description
+}
+
+func invalidField(field, format string, args ...interface{}) *InvalidFieldError {
+	return &InvalidFieldError{Field: field, Description: fmt.Sprintf(format, args...)}
+}
+
+// places the error within an enclosing field, prefixing its description
+func (e *InvalidFieldError) within(parent, format string, args ...interface{}) *InvalidFieldError {
+	field := parent
+	if len(e.Field) > 0 {
+		field += "." + e.Field
+	}
+	return &InvalidFieldError{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...) + ": " + e.Description,
+	}
+}
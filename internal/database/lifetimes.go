@@ -0,0 +1,63 @@
+package database
+
+import (
+	"encoding/hex"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+)
+
+const (
+	// how long verification codes can be exchanged if not configured
+	defaultVerificationCodeTTL = 15 * time.Minute
+	// how long authorization keys can be used if not configured
+	defaultAuthorizationKeyTTL = 24 * time.Hour
+	// how many failed exchanges naming a verification code it survives if not configured, e.g.
+	// replays of a code that was already exchanged
+	defaultMaxVerificationFailures = 10
+)
+
+// lifetimes of authorization keys and verification codes
+type lifetimes struct {
+	verificationCodeTTL time.Duration
+	// failed exchanges naming a verification code that it survives
+	verificationFailures int
+	keyTTL               time.Duration
+	authorityKeyTTLs     map[string]time.Duration
+}
+
+func newLifetimes(cfg *config.Config) lifetimes {
+	l := lifetimes{
+		verificationCodeTTL:  cfg.Authorization.VerificationCodeTTL,
+		verificationFailures: cfg.Authorization.MaxVerificationFailures,
+		keyTTL:               cfg.Authorization.KeyTTL,
+		authorityKeyTTLs:     cfg.Authorization.AuthorityKeyTTLs,
+	}
+	if l.verificationCodeTTL == 0 {
+		l.verificationCodeTTL = defaultVerificationCodeTTL
+	}
+	if l.verificationFailures == 0 {
+		l.verificationFailures = defaultMaxVerificationFailures
+	}
+	if l.keyTTL == 0 {
+		l.keyTTL = defaultAuthorizationKeyTTL
+	}
+	return l
+}
+
+// returns how long authorization keys issued by the given authority remain valid
+func (l lifetimes) authorizationKey(authority_id []byte) time.Duration {
+	if ttl, found := l.authorityKeyTTLs[hex.EncodeToString(authority_id)]; found {
+		return ttl
+	}
+	return l.keyTTL
+}
+
+// returns how long a verification code issued at the given time can be exchanged. A
+// verification code is useless once its authorization key has expired
+func (l lifetimes) verificationCode(issued_at, key_expires_at time.Time) time.Duration {
+	if remaining := key_expires_at.Sub(issued_at); remaining < l.verificationCodeTTL {
+		return remaining
+	}
+	return l.verificationCodeTTL
+}
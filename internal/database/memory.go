@@ -0,0 +1,693 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/google/uuid"
+)
+
+type memAuthority struct {
+	authority_id []byte
+	name         string
+	disabled     bool
+	created_at   time.Time
+	api_keys     []*memApiKey
+}
+
+type memApiKey struct {
+	api_key    []byte
+	authority  *memAuthority
+	revoked_at *time.Time
+}
+
+type memAuthorizationKey struct {
+	authorization_key []byte
+	api_key           *memApiKey
+	permitted_start   time.Time
+	permitted_end     time.Time
+	issued_at         time.Time
+	expires_at        time.Time
+	redeemed_at       *time.Time
+	redeemed_report   []byte
+}
+
+type memVerificationCode struct {
+	code              string
+	authorization_key *memAuthorizationKey
+	expires_at        time.Time
+	exchanged_at      *time.Time
+	failed_attempts   int
+}
+
+type memReportedKey struct {
+	tek               *proto.TimestampedTEK
+	enin              time.Time
+	authorization_key *memAuthorizationKey
+	// position in upload order; plays the role of upload_xid for download cursors
+	seq int64
+}
+
+// MemoryStore keeps authorities, authorization keys and diagnosis keys in memory. It has
+// the same validation and semantics as Database, and is meant for tests and local demos:
+// nothing survives a restart.
+type MemoryStore struct {
+	sync.Mutex
+	lifetimes lifetimes
+	// all maps are keyed by the raw bytes (or digits) of their identifier
+	authorities        map[string]*memAuthority
+	api_keys           map[string]*memApiKey
+	authorization_keys map[string]*memAuthorizationKey
+	verification_codes map[string]*memVerificationCode
+	reported_keys      map[string]*memReportedKey
+	next_seq           int64
+	publishing         bool
+	publish_state      map[string]memPublishState
+}
+
+type memPublishState struct {
+	cursor          string
+	published_until time.Time
+}
+
+// Creates a new, empty MemoryStore from the given configuration
+func NewMemoryStore(cfg *config.Config) (*MemoryStore, error) {
+	if err := config.Check(cfg, config.AuthorizationSection); err != nil {
+		return nil, fmt.Errorf("Invalid config for memory store: %w", err)
+	}
+	return &MemoryStore{
+		lifetimes:          newLifetimes(cfg),
+		authorities:        make(map[string]*memAuthority),
+		api_keys:           make(map[string]*memApiKey),
+		authorization_keys: make(map[string]*memAuthorizationKey),
+		verification_codes: make(map[string]*memVerificationCode),
+		reported_keys:      make(map[string]*memReportedKey),
+		next_seq:           1,
+		publish_state:      make(map[string]memPublishState),
+	}, nil
+}
+
+// Creates a new MemoryStore holding the fake health authority from the docker setup.
+// Helpful for testing.
+func NewMemoryStoreWithInsecureDefaults() (*MemoryStore, error) {
+	m, err := NewMemoryStore(&config.Config{})
+	if err != nil {
+		return nil, err
+	}
+	authority_id, _ := hex.DecodeString("da250d7fbffca634bf9b38e9430508bb")
+	api_key, _ := hex.DecodeString("c3b9b61b687b895aff09eb072fb07d33")
+	m.addAuthority(authority_id, "Fake Health Authority #1", api_key)
+	return m, nil
+}
+
+// The memory store is always healthy
+func (m *MemoryStore) CheckHealth(ctx context.Context) error {
+	return nil
+}
+
+func (m *MemoryStore) Close() {}
+
+// must be called with the lock held
+func (m *MemoryStore) addAuthority(authority_id []byte, name string, api_key []byte) *memAuthority {
+	authority := &memAuthority{
+		authority_id: authority_id,
+		name:         name,
+		created_at:   time.Now().UTC(),
+	}
+	m.authorities[string(authority_id)] = authority
+	m.addApiKey(authority, api_key)
+	return authority
+}
+
+// must be called with the lock held
+func (m *MemoryStore) addApiKey(authority *memAuthority, api_key []byte) {
+	key := &memApiKey{
+		api_key:   api_key,
+		authority: authority,
+	}
+	authority.api_keys = append(authority.api_keys, key)
+	m.api_keys[string(api_key)] = key
+}
+
+// must be called with the lock held
+func (m *MemoryStore) getAuthority(authority_id []byte) (*memAuthority, error) {
+	authority, found := m.authorities[string(authority_id)]
+	if !found {
+		return nil, newKindError(ErrNotFound, "No health authority with authority_id %x", authority_id)
+	}
+	return authority, nil
+}
+
+func (a *memAuthority) proto() *proto.Authority {
+	var active uint32
+	for _, key := range a.api_keys {
+		if key.revoked_at == nil {
+			active++
+		}
+	}
+	return &proto.Authority{
+		AuthorityId:   a.authority_id,
+		Name:          a.name,
+		Disabled:      a.disabled,
+		ActiveApiKeys: active,
+		CreatedAt:     a.created_at.Format(time.RFC3339),
+	}
+}
+
+// Look up the health authority an active api_key belongs to
+func (m *MemoryStore) AuthenticateApiKey(ctx context.Context, api_key []byte) (*proto.Authority, error) {
+	m.Lock()
+	defer m.Unlock()
+	key, found := m.api_keys[string(api_key)]
+	if !found || key.revoked_at != nil || key.authority.disabled {
+		return nil, ErrInvalidApiKey
+	}
+	return key.authority.proto(), nil
+}
+
+// Look up the health authority a verified client certificate belongs to, along with its
+// newest active api_key
+func (m *MemoryStore) AuthenticateClientAuthority(ctx context.Context, authority_id []byte) (*proto.Authority, []byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	authority, found := m.authorities[string(authority_id)]
+	if !found || authority.disabled {
+		return nil, nil, ErrInvalidClientAuthority
+	}
+	for idx := len(authority.api_keys) - 1; idx >= 0; idx-- {
+		if key := authority.api_keys[idx]; key.revoked_at == nil {
+			return authority.proto(), key.api_key, nil
+		}
+	}
+	return nil, nil, ErrInvalidClientAuthority
+}
+
+// Create a one-time use authorization key to be given to a patient. If the request asks for
+// a verification code, one is generated for the key as well.
+func (m *MemoryStore) CreateAuthorizationKey(ctx context.Context, request *proto.TokenRequest) (*AuthorizationKey, error) {
+	authKeysCreateAttempts.Inc()
+	if err := checkTokenRequest(request); err != nil {
+		return nil, fmt.Errorf("Invalid TokenRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	permitted_start, err := time.Parse(time.RFC3339, request.PermittedRangeStart)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse permitted_range_start: %w", err)
+	}
+	permitted_end, err := time.Parse(time.RFC3339, request.PermittedRangeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse permitted_range_end: %w", err)
+	}
+	one_time_auth_key, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("Could not generate one-time auth key: %w", err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	api_key, found := m.api_keys[string(request.ApiKey)]
+	if !found || api_key.revoked_at != nil || api_key.authority.disabled {
+		return nil, ErrInvalidApiKey
+	} else if err := checkClientAuthority(ctx, api_key.authority.authority_id); err != nil {
+		return nil, err
+	}
+	authority := api_key.authority
+	log.Infof("Generating one-time auth key for authority %s (%x)", authority.name, authority.authority_id)
+
+	issued_at := time.Now().UTC()
+	key := &memAuthorizationKey{
+		authorization_key: one_time_auth_key[:],
+		api_key:           api_key,
+		permitted_start:   permitted_start.UTC(),
+		permitted_end:     permitted_end.UTC(),
+		issued_at:         issued_at,
+		expires_at:        issued_at.Add(m.lifetimes.authorizationKey(authority.authority_id)),
+	}
+	created := &AuthorizationKey{
+		Key:       key.authorization_key,
+		ExpiresAt: key.expires_at,
+	}
+
+	if request.VerificationCodeLength > 0 {
+		code, err := m.createVerificationCode(key, request.VerificationCodeLength, m.lifetimes.verificationCode(issued_at, key.expires_at))
+		if err != nil {
+			return nil, err
+		}
+		created.VerificationCode = code.code
+		created.VerificationCodeExpiresAt = code.expires_at
+	}
+	m.authorization_keys[string(key.authorization_key)] = key
+	authKeysCreated.Inc()
+	return created, nil
+}
+
+// must be called with the lock held
+func (m *MemoryStore) createVerificationCode(key *memAuthorizationKey, length uint32, ttl time.Duration) (*memVerificationCode, error) {
+	now := time.Now().UTC()
+	for attempt := 0; attempt < maxVerificationCodeAttempts; attempt++ {
+		code, err := generateVerificationCode(length)
+		if err != nil {
+			return nil, fmt.Errorf("Could not generate verification code: %w", err)
+		}
+		if existing, found := m.verification_codes[code]; found && existing.exchanged_at == nil && existing.expires_at.After(now) {
+			continue
+		}
+		created := &memVerificationCode{
+			code:              code,
+			authorization_key: key,
+			expires_at:        now.Add(ttl),
+		}
+		m.verification_codes[code] = created
+		verificationCodesCreated.Inc()
+		return created, nil
+	}
+	return nil, errors.New("Could not generate a unique verification code; try a longer code")
+}
+
+// Exchanges a verification code for the authorization key it was issued with. A code can
+// only be exchanged once, and only before it expires or is burned. A failed exchange only
+// counts against the code it named, if that exists; guesses are limited per client by the
+// server instead, and codes are long enough that guessing any active one is unlikely
+func (m *MemoryStore) ExchangeVerificationCode(ctx context.Context, request *proto.VerificationCodeRequest) ([]byte, error) {
+	verificationCodeExchangeAttempts.Inc()
+	if err := checkVerificationCodeRequest(request); err != nil {
+		return nil, fmt.Errorf("Invalid VerificationCodeRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	m.Lock()
+	defer m.Unlock()
+	now := time.Now().UTC()
+	code, found := m.verification_codes[request.VerificationCode]
+	if !found {
+		return nil, ErrInvalidVerificationCode
+	} else if !m.exchangeable(code, now) {
+		code.failed_attempts++
+		if code.failed_attempts == m.lifetimes.verificationFailures {
+			log.Warnf("Burned verification code after %d failed exchanges", code.failed_attempts)
+			verificationCodesBurned.Inc()
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+	code.exchanged_at = &now
+	log.Infof("Exchanged verification code for auth key %x", code.authorization_key.authorization_key)
+	verificationCodesExchanged.Inc()
+	return code.authorization_key.authorization_key, nil
+}
+
+// tells whether the code can still be exchanged. Must be called with the lock held
+func (m *MemoryStore) exchangeable(code *memVerificationCode, now time.Time) bool {
+	return code.exchanged_at == nil && code.expires_at.After(now) && code.failed_attempts < m.lifetimes.verificationFailures
+}
+
+func (m *MemoryStore) AddReport(ctx context.Context, report *proto.Report) error {
+	addReportAttempts.Inc()
+	if err := checkReport(report); err != nil {
+		return fmt.Errorf("Invalid Report: %w", err)
+	}
+	log := logging.FromContext(ctx)
+	report_id, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("Could not generate report id: %w", err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	log.Infof("New report with auth key %x", report.AuthorizationKey)
+	now := time.Now().UTC()
+	key, found := m.authorization_keys[string(report.AuthorizationKey)]
+	if !found {
+		return ErrUnknownAuthorizationKey
+	} else if key.redeemed_at != nil {
+		addReportRedeemedKey.Inc()
+		return ErrAuthorizationKeyRedeemed
+	} else if !key.expires_at.After(now) {
+		addReportExpiredKey.Inc()
+		return ErrAuthorizationKeyExpired
+	}
+
+	// check every TEK before storing any of them, so that an invalid report changes nothing
+	for idx, tstek := range report.Reports {
+		timestamp := eninToTimestamp(tstek.ENIN)
+		if !timestampInRange(timestamp, key.permitted_start, key.permitted_end) {
+			return invalidField(fmt.Sprintf("reports[%d].ENIN", idx), "Report %d (%s) was not in valid range [%s, %s])", idx, timestamp, key.permitted_start, key.permitted_end)
+		}
+	}
+	for _, tstek := range report.Reports {
+		if _, found := m.reported_keys[string(tstek.TEK)]; found {
+			continue
+		}
+		stored := &proto.TimestampedTEK{
+			TEK:                   tstek.TEK,
+			ENIN:                  tstek.ENIN,
+			RollingPeriod:         uint32(rollingPeriod(tstek)),
+			TransmissionRiskLevel: tstek.TransmissionRiskLevel,
+			ReportType:            tstek.ReportType,
+		}
+		if days := daysSinceOnset(tstek); days != nil {
+			stored.DaysSinceOnsetOfSymptoms = &wrappers.Int32Value{Value: *days}
+		}
+		m.reported_keys[string(tstek.TEK)] = &memReportedKey{
+			tek:               stored,
+			enin:              eninToTimestamp(tstek.ENIN),
+			authorization_key: key,
+			seq:               m.next_seq,
+		}
+		m.next_seq++
+	}
+	key.redeemed_at = &now
+	key.redeemed_report = report_id[:]
+	addReportSuccess.Inc()
+	return nil
+}
+
+// Streams the keys matching the request. Resumable requests end with a message carrying
+// the cursor for the next download instead of a record
+func (m *MemoryStore) GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.GetDiagnosisKeyResponse, chan error) {
+	getDiagnosisKeysAttempts.Inc()
+	results := make(chan *proto.GetDiagnosisKeyResponse)
+	errchan := make(chan error, 1)
+
+	if err := checkGetKeyRequest(request); err != nil {
+		errchan <- fmt.Errorf("Invalid GetKeyRequest: %w", err)
+		return results, errchan
+	}
+	log := logging.FromContext(ctx)
+	log.Debugf("Fetching keys for query: health_authority=%x, enin=%d, hrange=%v, since=%s", request.AuthorityId, request.ENIN, request.Hrange, request.Since)
+
+	ranges, err := requestRanges(request)
+	if err != nil {
+		errchan <- fmt.Errorf("Could not construct query for GetKeyRequest: %w", err)
+		return results, errchan
+	}
+	var since int64
+	if len(request.Since) > 0 {
+		if since, err = decodeCursor(request.Since); err != nil {
+			errchan <- fmt.Errorf("Could not construct query for GetKeyRequest: %w", err)
+			return results, errchan
+		}
+	}
+
+	start := time.Now()
+	m.Lock()
+	horizon := m.next_seq
+	var matched []*memReportedKey
+	for _, reported := range m.reported_keys {
+		if reported.seq < since {
+			continue
+		} else if len(request.AuthorityId) > 0 && !bytes.Equal(reported.authorization_key.api_key.authority.authority_id, request.AuthorityId) {
+			continue
+		}
+		in_range := true
+		for _, r := range ranges {
+			in_range = in_range && r.contains(reported.enin)
+		}
+		if in_range {
+			matched = append(matched, reported)
+		}
+	}
+	m.Unlock()
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].seq < matched[j].seq
+	})
+
+	go func() {
+		defer close(errchan)
+		for _, reported := range matched {
+			select {
+			case results <- &proto.GetDiagnosisKeyResponse{Record: reported.tek}:
+			case <-ctx.Done():
+				errchan <- fmt.Errorf("Could not download reported keys: %w", ctx.Err())
+				return
+			}
+		}
+		if request.Resumable || len(request.Since) > 0 {
+			select {
+			case results <- &proto.GetDiagnosisKeyResponse{NextCursor: encodeCursor(horizon)}:
+			case <-ctx.Done():
+				errchan <- fmt.Errorf("Could not download reported keys: %w", ctx.Err())
+				return
+			}
+		}
+		getDiagnosisKeysSuccess.Inc()
+		getDiagnosisKeysTime.Observe(float64(time.Since(start).Milliseconds()))
+		close(results)
+	}()
+
+	return results, errchan
+}
+
+// Registers a new health authority and returns it along with its first api_key
+func (m *MemoryStore) CreateAuthority(ctx context.Context, request *proto.CreateAuthorityRequest) (*proto.Authority, []byte, error) {
+	if err := checkCreateAuthorityRequest(request); err != nil {
+		return nil, nil, fmt.Errorf("Invalid CreateAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	authority_id := request.AuthorityId
+	if len(authority_id) == 0 {
+		id, err := uuid.NewRandom()
+		if err != nil {
+			return nil, nil, fmt.Errorf("Could not generate authority_id: %w", err)
+		}
+		authority_id = id[:]
+	}
+	api_key, err := newApiKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if _, found := m.authorities[string(authority_id)]; found {
+		return nil, nil, newKindError(ErrAlreadyExists, "Health authority %x already exists", authority_id)
+	}
+	authority := m.addAuthority(authority_id, request.Name, api_key)
+	log.Infof("Created health authority %s (%x)", request.Name, authority_id)
+	return authority.proto(), api_key, nil
+}
+
+// Returns all registered health authorities, oldest first
+func (m *MemoryStore) ListAuthorities(ctx context.Context, request *proto.ListAuthoritiesRequest) ([]*proto.Authority, error) {
+	m.Lock()
+	defer m.Unlock()
+	var authorities []*memAuthority
+	for _, authority := range m.authorities {
+		if request.GetIncludeDisabled() || !authority.disabled {
+			authorities = append(authorities, authority)
+		}
+	}
+	sort.Slice(authorities, func(i, j int) bool {
+		return authorities[i].created_at.Before(authorities[j].created_at)
+	})
+	var list []*proto.Authority
+	for _, authority := range authorities {
+		list = append(list, authority.proto())
+	}
+	return list, nil
+}
+
+// Changes the name of a health authority
+func (m *MemoryStore) RenameAuthority(ctx context.Context, request *proto.RenameAuthorityRequest) (*proto.Authority, error) {
+	if err := checkRenameAuthorityRequest(request); err != nil {
+		return nil, fmt.Errorf("Invalid RenameAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	m.Lock()
+	defer m.Unlock()
+	authority, err := m.getAuthority(request.AuthorityId)
+	if err != nil {
+		return nil, err
+	}
+	authority.name = request.Name
+	log.Infof("Renamed health authority %x to %s", request.AuthorityId, request.Name)
+	return authority.proto(), nil
+}
+
+// Disables (or re-enables) a health authority. Disabled authorities cannot create
+// authorization keys, but keys they already issued remain valid
+func (m *MemoryStore) DisableAuthority(ctx context.Context, request *proto.DisableAuthorityRequest) (*proto.Authority, error) {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return nil, fmt.Errorf("Invalid DisableAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	m.Lock()
+	defer m.Unlock()
+	authority, err := m.getAuthority(request.AuthorityId)
+	if err != nil {
+		return nil, err
+	}
+	authority.disabled = !request.Reenable
+	log.Infof("Set disabled=%t for health authority %x", authority.disabled, request.AuthorityId)
+	return authority.proto(), nil
+}
+
+// Deletes a health authority and all of its api keys. Authorities that have issued
+// authorization keys can only be disabled
+func (m *MemoryStore) DeleteAuthority(ctx context.Context, request *proto.DeleteAuthorityRequest) error {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return fmt.Errorf("Invalid DeleteAuthorityRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	m.Lock()
+	defer m.Unlock()
+	authority, err := m.getAuthority(request.AuthorityId)
+	if err != nil {
+		return err
+	}
+	for _, key := range m.authorization_keys {
+		if key.api_key.authority == authority {
+			return newKindError(ErrInUse, "Health authority %x has issued authorization keys; disable it instead", request.AuthorityId)
+		}
+	}
+	for _, api_key := range authority.api_keys {
+		delete(m.api_keys, string(api_key.api_key))
+	}
+	delete(m.authorities, string(request.AuthorityId))
+	log.Infof("Deleted health authority %x", request.AuthorityId)
+	return nil
+}
+
+// Issues an additional api_key for an existing health authority
+func (m *MemoryStore) IssueApiKey(ctx context.Context, request *proto.IssueApiKeyRequest) ([]byte, error) {
+	if err := checkAuthorityID(request.GetAuthorityId()); err != nil {
+		return nil, fmt.Errorf("Invalid IssueApiKeyRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+	api_key, err := newApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	authority, err := m.getAuthority(request.AuthorityId)
+	if err != nil {
+		return nil, err
+	}
+	m.addApiKey(authority, api_key)
+	log.Infof("Issued new api_key for health authority %x", request.AuthorityId)
+	return api_key, nil
+}
+
+// Revokes an api_key so that it can no longer create authorization keys
+func (m *MemoryStore) RevokeApiKey(ctx context.Context, request *proto.RevokeApiKeyRequest) error {
+	if err := checkApiKey(request.GetApiKey()); err != nil {
+		return fmt.Errorf("Invalid RevokeApiKeyRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	m.Lock()
+	defer m.Unlock()
+	api_key, found := m.api_keys[string(request.ApiKey)]
+	if !found || api_key.revoked_at != nil {
+		return newKindError(ErrNotFound, "api_key is unknown or already revoked")
+	}
+	now := time.Now().UTC()
+	api_key.revoked_at = &now
+	log.Infof("Revoked an api_key of health authority %x", api_key.authority.authority_id)
+	return nil
+}
+
+// Deletes data past its retention period with the same rules as Database.PurgeExpired
+func (m *MemoryStore) PurgeExpired(ctx context.Context, policy RetentionPolicy) (*PurgeResult, error) {
+	if policy.Period <= 0 {
+		return nil, errors.New("Retention period must be positive")
+	}
+	for authority := range policy.AuthorityPeriods {
+		if _, err := hex.DecodeString(authority); err != nil {
+			return nil, fmt.Errorf("Invalid authority_id %q in retention policy: %w", authority, err)
+		}
+	}
+	now := time.Now().UTC()
+	cutoff := func(key *memAuthorizationKey) time.Time {
+		authority := hex.EncodeToString(key.api_key.authority.authority_id)
+		if period, found := policy.AuthorityPeriods[authority]; found {
+			return now.Add(-period)
+		}
+		return now.Add(-policy.Period)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	result := &PurgeResult{}
+	// tracks authorization keys that still have reported keys after the purge
+	referenced := make(map[*memAuthorizationKey]bool)
+	for tek, reported := range m.reported_keys {
+		if reported.enin.Before(cutoff(reported.authorization_key)) {
+			result.ReportedKeys++
+			if !policy.DryRun {
+				delete(m.reported_keys, tek)
+			}
+		} else {
+			referenced[reported.authorization_key] = true
+		}
+	}
+	purged := make(map[*memAuthorizationKey]bool)
+	for id, key := range m.authorization_keys {
+		if !referenced[key] && key.issued_at.Before(cutoff(key)) {
+			purged[key] = true
+			result.AuthorizationKeys++
+			if !policy.DryRun {
+				delete(m.authorization_keys, id)
+			}
+		}
+	}
+	for code, verification := range m.verification_codes {
+		if purged[verification.authorization_key] || verification.expires_at.Before(now) {
+			result.VerificationCodes++
+			if !policy.DryRun {
+				delete(m.verification_codes, code)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Runs f unless it is already publishing, like Database.WithPublishLock
+func (m *MemoryStore) WithPublishLock(ctx context.Context, f func(ctx context.Context) error) (bool, error) {
+	m.Lock()
+	if m.publishing {
+		m.Unlock()
+		return false, nil
+	}
+	m.publishing = true
+	m.Unlock()
+	defer func() {
+		m.Lock()
+		m.publishing = false
+		m.Unlock()
+	}()
+	return true, f(ctx)
+}
+
+// Returns the cursor and end time of the last run that published the authority's keys, or
+// zero values before the first one
+func (m *MemoryStore) PublishState(ctx context.Context, authority_id []byte) (string, time.Time, error) {
+	m.Lock()
+	defer m.Unlock()
+	state := m.publish_state[string(authority_id)]
+	return state.cursor, state.published_until, nil
+}
+
+// Records where the next run publishing the authority's keys continues
+func (m *MemoryStore) SavePublishState(ctx context.Context, authority_id []byte, cursor string, published_until time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	m.publish_state[string(authority_id)] = memPublishState{cursor: cursor, published_until: published_until.UTC()}
+	return nil
+}
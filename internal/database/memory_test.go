@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/proto"
+)
+
+// a MemoryStore with one health authority, whose api_key is returned
+func newTestStore(t *testing.T, cfg *config.Config) (*MemoryStore, []byte) {
+	t.Helper()
+	store, err := NewMemoryStore(cfg)
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+	_, api_key, err := store.CreateAuthority(context.Background(), &proto.CreateAuthorityRequest{Name: "Test Health Authority"})
+	if err != nil {
+		t.Fatalf("could not create authority: %v", err)
+	}
+	return store, api_key
+}
+
+// a token request permitting keys from the last two weeks
+func testTokenRequest(api_key []byte, codeLength uint32) *proto.TokenRequest {
+	now := time.Now().UTC()
+	return &proto.TokenRequest{
+		ApiKey:                 api_key,
+		PermittedRangeStart:    now.Add(-14 * 24 * time.Hour).Format(time.RFC3339),
+		PermittedRangeEnd:      now.Format(time.RFC3339),
+		VerificationCodeLength: codeLength,
+	}
+}
+
+// a report of a single TEK with the given first byte, from the given time
+func testReport(authorization_key []byte, tek byte, at time.Time) *proto.Report {
+	key := make([]byte, 16)
+	key[0] = tek
+	return &proto.Report{
+		AuthorizationKey: authorization_key,
+		Reports:          []*proto.TimestampedTEK{{TEK: key, ENIN: uint32(at.Unix() / 600), RollingPeriod: 144}},
+	}
+}
+
+// drains a GetDiagnosisKeys stream, returning its records and, for resumable requests, the
+// next cursor
+func collectKeys(t *testing.T, store Store, request *proto.GetKeyRequest) ([]*proto.TimestampedTEK, string) {
+	t.Helper()
+	results, errchan := store.GetDiagnosisKeys(context.Background(), request)
+	var (
+		records []*proto.TimestampedTEK
+		cursor  string
+	)
+	for {
+		select {
+		case err := <-errchan:
+			if err != nil {
+				t.Fatalf("could not get keys: %v", err)
+			}
+			return records, cursor
+		case resp := <-results:
+			if resp == nil {
+				return records, cursor
+			} else if resp.Record != nil {
+				records = append(records, resp.Record)
+			} else {
+				cursor = resp.NextCursor
+			}
+		}
+	}
+}
+
+func TestAddReport(t *testing.T) {
+	ctx := context.Background()
+	at := time.Now().UTC().Add(-24 * time.Hour)
+	store, api_key := newTestStore(t, &config.Config{})
+	key, err := store.CreateAuthorizationKey(ctx, testTokenRequest(api_key, 0))
+	if err != nil {
+		t.Fatalf("could not create authorization key: %v", err)
+	}
+
+	var invalid *InvalidFieldError
+	if err := store.AddReport(ctx, testReport(key.Key, 1, at.Add(-30*24*time.Hour))); !errors.As(err, &invalid) || invalid.Field != "reports[0].ENIN" {
+		t.Fatalf("expected reports[0].ENIN to be out of range, got %v", err)
+	}
+	if err := store.AddReport(ctx, testReport(key.Key, 1, at)); err != nil {
+		t.Fatalf("could not add report: %v", err)
+	}
+	if err := store.AddReport(ctx, testReport(key.Key, 2, at)); !errors.Is(err, ErrAuthorizationKeyRedeemed) {
+		t.Fatalf("expected ErrAuthorizationKeyRedeemed, got %v", err)
+	}
+	if err := store.AddReport(ctx, testReport(make([]byte, 16), 3, at)); !errors.Is(err, ErrUnknownAuthorizationKey) {
+		t.Fatalf("expected ErrUnknownAuthorizationKey, got %v", err)
+	}
+
+	records, _ := collectKeys(t, store, &proto.GetKeyRequest{Hrange: &proto.HistoricalRange{Days: 14}})
+	if len(records) != 1 || records[0].TEK[0] != 1 {
+		t.Fatalf("expected only the TEK of the first report, got %v", records)
+	}
+}
+
+func TestAddReportExpiredKey(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Authorization.KeyTTL = time.Nanosecond
+	store, api_key := newTestStore(t, cfg)
+	key, err := store.CreateAuthorizationKey(ctx, testTokenRequest(api_key, 0))
+	if err != nil {
+		t.Fatalf("could not create authorization key: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := store.AddReport(ctx, testReport(key.Key, 1, time.Now().UTC().Add(-time.Hour))); !errors.Is(err, ErrAuthorizationKeyExpired) {
+		t.Fatalf("expected ErrAuthorizationKeyExpired, got %v", err)
+	}
+}
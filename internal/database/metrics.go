@@ -1,6 +1,7 @@
 package database
 
 import (
+	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -14,6 +15,22 @@ var (
 		Name: "commons_authorization_keys_created",
 		Help: "Total number of authorization keys created successfully",
 	})
+	verificationCodesCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_verification_codes_created",
+		Help: "Total number of verification codes created",
+	})
+	verificationCodeExchangeAttempts = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_verification_code_exchange_attempts",
+		Help: "Number of attempts to exchange a verification code",
+	})
+	verificationCodesExchanged = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_verification_codes_exchanged",
+		Help: "Number of verification codes successfully exchanged for authorization keys",
+	})
+	verificationCodesBurned = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_verification_codes_burned",
+		Help: "Number of verification codes burned after too many failed exchanges naming them",
+	})
 	addReportAttempts = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "commons_add_report_attempts",
 		Help: "Number of attempts to add reports",
@@ -22,6 +39,14 @@ var (
 		Name: "commons_add_report_success",
 		Help: "Number of successfully added reports",
 	})
+	addReportRedeemedKey = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_add_report_redeemed_key",
+		Help: "Number of reports rejected because their authorization key was already redeemed",
+	})
+	addReportExpiredKey = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_add_report_expired_key",
+		Help: "Number of reports rejected because their authorization key had expired",
+	})
 	getDiagnosisKeysAttempts = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "commons_get_diagnosis_keys_attempts",
 		Help: "Number of download attempts",
@@ -34,4 +59,67 @@ var (
 		Name: "commons_get_diagnosis_keys_time",
 		Help: "Milliseconds elapsed to download diagnosis keys",
 	})
+	keyCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_key_cache_lookups",
+		Help: "Number of downloads looked up in the key cache, by result: hit, miss or uncacheable",
+	}, []string{"result"})
+	keyCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_key_cache_evictions",
+		Help: "Number of results dropped from the key cache, by reason: expired, size, invalidated or replaced",
+	}, []string{"reason"})
+	keyCacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "commons_key_cache_entries",
+		Help: "Number of results held in the key cache",
+	})
+	keyCacheKeys = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "commons_key_cache_keys",
+		Help: "Number of diagnosis keys held in the key cache",
+	})
+)
+
+var (
+	poolAcquiredConns = prometheus.NewDesc("commons_db_pool_acquired_conns",
+		"Number of connections currently acquired from the database pool", nil, nil)
+	poolIdleConns = prometheus.NewDesc("commons_db_pool_idle_conns",
+		"Number of idle connections in the database pool", nil, nil)
+	poolTotalConns = prometheus.NewDesc("commons_db_pool_total_conns",
+		"Number of connections in the database pool, including ones being established", nil, nil)
+	poolMaxConns = prometheus.NewDesc("commons_db_pool_max_conns",
+		"Maximum size of the database pool", nil, nil)
+	poolAcquireCount = prometheus.NewDesc("commons_db_pool_acquires",
+		"Number of successful acquires from the database pool", nil, nil)
+	poolAcquireSeconds = prometheus.NewDesc("commons_db_pool_acquire_wait_seconds",
+		"Total seconds spent waiting for successful acquires from the database pool", nil, nil)
+	poolEmptyAcquireCount = prometheus.NewDesc("commons_db_pool_empty_acquires",
+		"Number of acquires that had to wait for a connection because the pool was empty", nil, nil)
+	poolCanceledAcquireCount = prometheus.NewDesc("commons_db_pool_canceled_acquires",
+		"Number of acquires cancelled by their context", nil, nil)
 )
+
+// exports pgxpool.Stat() of the database pool whenever Prometheus scrapes
+type poolCollector struct {
+	pool *pgxpool.Pool
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolAcquiredConns
+	ch <- poolIdleConns
+	ch <- poolTotalConns
+	ch <- poolMaxConns
+	ch <- poolAcquireCount
+	ch <- poolAcquireSeconds
+	ch <- poolEmptyAcquireCount
+	ch <- poolCanceledAcquireCount
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(poolAcquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(poolIdleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(poolTotalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(poolMaxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolAcquireSeconds, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(poolEmptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(poolCanceledAcquireCount, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
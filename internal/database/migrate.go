@@ -0,0 +1,216 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/logging"
+	"github.com/jackc/pgx/v4"
+)
+
+// arbitrary constant identifying schema migrations among postgres advisory locks
+const migrationLockID = 0x6d69677261746573
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`
+
+// ErrSchemaOutdated is returned by CheckSchema if migrations are pending
+var ErrSchemaOutdated = errors.New("database schema is older than this server; run the pending migrations")
+
+// MigrationStatus describes one known migration and whether it has been applied
+type MigrationStatus struct {
+	Version int
+	Name    string
+	// nil if the migration has not been applied
+	AppliedAt *time.Time
+}
+
+// takes the migration lock for the rest of the transaction and returns the current
+// schema version. Concurrent migrators wait for each other here
+func lockSchema(ctx context.Context, txn pgx.Tx) (int, error) {
+	if _, err := txn.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockID); err != nil {
+		return 0, fmt.Errorf("Could not take migration lock: %w", err)
+	}
+	if _, err := txn.Exec(ctx, createMigrationsTable); err != nil {
+		return 0, fmt.Errorf("Could not create schema_migrations: %w", err)
+	}
+	var version int
+	if err := txn.QueryRow(ctx, `SELECT COALESCE(max(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("Could not read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// reading the schema version should not create schema_migrations, so readers check
+// whether it exists first
+func migrationsTableExists(ctx context.Context, txn pgx.Tx) (bool, error) {
+	var exists bool
+	if err := txn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("Could not look for schema_migrations: %w", err)
+	}
+	return exists, nil
+}
+
+// Returns the version of the newest applied migration, or 0 if none have been applied
+func (db *Database) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		if exists, err := migrationsTableExists(ctx, txn); err != nil || !exists {
+			return err
+		}
+		if err := txn.QueryRow(ctx, `SELECT COALESCE(max(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+			return fmt.Errorf("Could not read schema version: %w", err)
+		}
+		return nil
+	})
+	return version, err
+}
+
+// Returns an error wrapping ErrSchemaOutdated if the database has not been migrated to
+// the RequiredSchemaVersion this server was built for. A newer schema is accepted, since
+// migrations are expected to stay compatible with the previous release during a rollout
+func (db *Database) CheckSchema(ctx context.Context) error {
+	version, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version < RequiredSchemaVersion {
+		return fmt.Errorf("%w (schema version %d, need %d)", ErrSchemaOutdated, version, RequiredSchemaVersion)
+	} else if version > RequiredSchemaVersion {
+		logging.FromContext(ctx).Warnf("Database schema version %d is newer than this server (%d)", version, RequiredSchemaVersion)
+	}
+	return nil
+}
+
+// Lists all known migrations along with when they were applied
+func (db *Database) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	applied := make(map[int]time.Time)
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		if exists, err := migrationsTableExists(ctx, txn); err != nil || !exists {
+			return err
+		}
+		rows, err := txn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+		if err != nil {
+			return fmt.Errorf("Could not read schema_migrations: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				version    int
+				applied_at time.Time
+			)
+			if err := rows.Scan(&version, &applied_at); err != nil {
+				return fmt.Errorf("Error reading schema_migrations: %w", err)
+			}
+			applied[version] = applied_at
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	for _, m := range migrations {
+		status := MigrationStatus{Version: m.version, Name: m.name}
+		if applied_at, found := applied[m.version]; found {
+			status.AppliedAt = &applied_at
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Applies all pending migrations in order, each in its own transaction, and returns the
+// versions that were applied
+func (db *Database) MigrateUp(ctx context.Context) ([]int, error) {
+	log := logging.FromContext(ctx)
+	var applied []int
+	for _, m := range migrations {
+		m := m
+		var skipped bool
+		err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+			version, err := lockSchema(ctx, txn)
+			if err != nil {
+				return err
+			} else if version >= m.version {
+				// applied earlier, or by another replica while we waited for the lock
+				skipped = true
+				return nil
+			}
+			log.Infof("Applying migration %d (%s)", m.version, m.name)
+			if _, err := txn.Exec(ctx, m.up); err != nil {
+				return fmt.Errorf("Could not apply migration %d (%s): %w", m.version, m.name, err)
+			}
+			_, err = txn.Exec(ctx, `INSERT INTO schema_migrations(version, name, applied_at) VALUES ($1, $2, $3)`,
+				m.version, m.name, time.Now().UTC())
+			if err != nil {
+				return fmt.Errorf("Could not record migration %d: %w", m.version, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return applied, err
+		}
+		if !skipped {
+			applied = append(applied, m.version)
+		}
+	}
+	return applied, nil
+}
+
+// Reverts the given number of most recently applied migrations, newest first, and returns
+// the versions that were reverted
+func (db *Database) MigrateDown(ctx context.Context, steps int) ([]int, error) {
+	if steps <= 0 {
+		return nil, errors.New("Number of migrations to revert must be positive")
+	}
+	log := logging.FromContext(ctx)
+	var reverted []int
+	for i := 0; i < steps; i++ {
+		var reverting *migration
+		err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+			version, err := lockSchema(ctx, txn)
+			if err != nil {
+				return err
+			} else if version == 0 {
+				return nil
+			}
+			m, err := findMigration(version)
+			if err != nil {
+				return err
+			}
+			reverting = m
+			log.Infof("Reverting migration %d (%s)", m.version, m.name)
+			if _, err := txn.Exec(ctx, m.down); err != nil {
+				return fmt.Errorf("Could not revert migration %d (%s): %w", m.version, m.name, err)
+			}
+			if _, err := txn.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, m.version); err != nil {
+				return fmt.Errorf("Could not record reverting migration %d: %w", m.version, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return reverted, err
+		} else if reverting == nil {
+			// nothing left to revert
+			break
+		}
+		reverted = append(reverted, reverting.version)
+	}
+	return reverted, nil
+}
+
+func findMigration(version int) (*migration, error) {
+	for idx := range migrations {
+		if migrations[idx].version == version {
+			return &migrations[idx], nil
+		}
+	}
+	return nil, fmt.Errorf("Schema version %d is unknown to this server; revert it with a newer release", version)
+}
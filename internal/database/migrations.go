@@ -0,0 +1,168 @@
+package database
+
+// Migrations are compiled into the binary so that a server always carries the schema it
+// was written against. Each migration is applied in its own transaction and recorded in
+// schema_migrations. Never edit a migration that has been released; add a new one instead.
+//
+// The statements use IF [NOT] EXISTS so that they also apply cleanly to databases created
+// from docker/diagnosis-key-pg/setup.sql, which holds the latest schema for development.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial_schema",
+		up: `
+CREATE TABLE IF NOT EXISTS health_authorities (
+    authority_id    BYTEA NOT NULL,
+    name            TEXT NOT NULL,
+    api_key         BYTEA NOT NULL,
+    UNIQUE(api_key),
+    PRIMARY KEY(authority_id, api_key)
+);
+
+CREATE TABLE IF NOT EXISTS authorization_keys (
+    authorization_key BYTEA PRIMARY KEY,
+    api_key           BYTEA REFERENCES health_authorities(api_key),
+    key_type          TEXT,
+    permitted_start   TIMESTAMP NOT NULL,
+    permitted_end     TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reported_keys (
+    TEK                BYTEA NOT NULL PRIMARY KEY,
+    ENIN               TIMESTAMP NOT NULL,
+    authorization_key  BYTEA NOT NULL REFERENCES authorization_keys(authorization_key),
+    uploaded_at        TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS enin_idx ON reported_keys(ENIN);
+CREATE INDEX IF NOT EXISTS hak_idx ON reported_keys(authorization_key);
+`,
+		down: `
+DROP TABLE IF EXISTS reported_keys;
+DROP TABLE IF EXISTS authorization_keys;
+DROP TABLE IF EXISTS health_authorities;
+`,
+	},
+	{
+		version: 2,
+		name:    "health_authority_administration",
+		up: `
+ALTER TABLE health_authorities
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS disabled   BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
+`,
+		down: `
+ALTER TABLE health_authorities
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS disabled,
+    DROP COLUMN IF EXISTS revoked_at;
+`,
+	},
+	{
+		version: 3,
+		name:    "verification_codes",
+		up: `
+CREATE TABLE IF NOT EXISTS verification_codes (
+    code              TEXT PRIMARY KEY,
+    authorization_key BYTEA NOT NULL REFERENCES authorization_keys(authorization_key),
+    expires_at        TIMESTAMP NOT NULL,
+    exchanged_at      TIMESTAMP
+);
+`,
+		down: `
+DROP TABLE IF EXISTS verification_codes;
+`,
+	},
+	{
+		version: 4,
+		name:    "authorization_key_expiry",
+		up: `
+ALTER TABLE authorization_keys
+    ADD COLUMN IF NOT EXISTS issued_at       TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS expires_at      TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS redeemed_at     TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS redeemed_report BYTEA;
+
+-- keys issued before expiry existed get the default lifetime, and keys that were
+-- already used to upload a report count as redeemed
+UPDATE authorization_keys SET expires_at = issued_at + INTERVAL '24 hours' WHERE expires_at IS NULL;
+UPDATE authorization_keys SET redeemed_at = issued_at
+    WHERE redeemed_at IS NULL AND EXISTS
+    (SELECT 1 FROM reported_keys WHERE reported_keys.authorization_key = authorization_keys.authorization_key);
+ALTER TABLE authorization_keys ALTER COLUMN expires_at SET NOT NULL;
+`,
+		down: `
+ALTER TABLE authorization_keys
+    DROP COLUMN IF EXISTS issued_at,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS redeemed_at,
+    DROP COLUMN IF EXISTS redeemed_report;
+`,
+	},
+	{
+		version: 5,
+		name:    "tek_metadata",
+		up: `
+ALTER TABLE reported_keys
+    ADD COLUMN IF NOT EXISTS rolling_period               INTEGER NOT NULL DEFAULT 144,
+    ADD COLUMN IF NOT EXISTS transmission_risk_level      INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS report_type                  INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS days_since_onset_of_symptoms INTEGER;
+`,
+		down: `
+ALTER TABLE reported_keys
+    DROP COLUMN IF EXISTS rolling_period,
+    DROP COLUMN IF EXISTS transmission_risk_level,
+    DROP COLUMN IF EXISTS report_type,
+    DROP COLUMN IF EXISTS days_since_onset_of_symptoms;
+`,
+	},
+	{
+		version: 6,
+		name:    "upload_cursors",
+		up: `
+-- existing rows all get the id of the migrating transaction, so any first download includes them
+ALTER TABLE reported_keys ADD COLUMN IF NOT EXISTS upload_xid BIGINT NOT NULL DEFAULT txid_current();
+CREATE INDEX IF NOT EXISTS upload_xid_idx ON reported_keys(upload_xid);
+`,
+		down: `
+DROP INDEX IF EXISTS upload_xid_idx;
+ALTER TABLE reported_keys DROP COLUMN IF EXISTS upload_xid;
+`,
+	},
+	{
+		version: 7,
+		name:    "verification_failures",
+		up: `
+ALTER TABLE verification_codes ADD COLUMN IF NOT EXISTS failed_attempts INTEGER NOT NULL DEFAULT 0;
+`,
+		down: `
+ALTER TABLE verification_codes DROP COLUMN IF EXISTS failed_attempts;
+`,
+	},
+	{
+		version: 8,
+		name:    "publish_state",
+		up: `
+CREATE TABLE IF NOT EXISTS publish_state (
+    authority_id    BYTEA PRIMARY KEY,
+    upload_cursor   TEXT NOT NULL,
+    published_until TIMESTAMP NOT NULL
+);
+`,
+		down: `
+DROP TABLE IF EXISTS publish_state;
+`,
+	},
+}
+
+// RequiredSchemaVersion is the version of the newest migration, which the server requires
+var RequiredSchemaVersion = migrations[len(migrations)-1].version
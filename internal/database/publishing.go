@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/logging"
+	"github.com/jackc/pgx/v4"
+)
+
+// arbitrary constant identifying publishing among postgres advisory locks
+const publishLockID = 0x636f6d6d6f6e74
+
+// Runs f unless another replica is already publishing, in which case f is not run and false
+// is returned. The lock belongs to a connection of its own rather than a transaction, since
+// publishing uploads to the blob store for a while; postgres releases it if the connection
+// is lost
+func (db *Database) WithPublishLock(ctx context.Context, f func(ctx context.Context) error) (bool, error) {
+	conn, err := db.pool.Acquire(ctx)
+	if err != nil {
+		return false, newKindError(ErrUnavailable, "Could not acquire connection from pool: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, publishLockID).Scan(&locked); err != nil {
+		return false, fmt.Errorf("Could not take publish lock: %w", err)
+	} else if !locked {
+		return false, nil
+	}
+	defer func() {
+		// unlocked even if ctx is done; otherwise the pooled connection would keep the lock
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, publishLockID); err != nil {
+			logging.FromContext(ctx).Errorf("Could not release publish lock: %s", err)
+			conn.Conn().Close(context.Background())
+		}
+	}()
+	return true, f(ctx)
+}
+
+// Returns the cursor and end time of the last run that published the authority's keys, or
+// zero values before the first one
+func (db *Database) PublishState(ctx context.Context, authority_id []byte) (string, time.Time, error) {
+	var (
+		cursor          string
+		published_until time.Time
+	)
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		err := txn.QueryRow(ctx, `SELECT upload_cursor, published_until FROM publish_state WHERE authority_id = $1`,
+			authority_id).Scan(&cursor, &published_until)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("Could not read publish state: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return cursor, published_until.UTC(), nil
+}
+
+// Records where the next run publishing the authority's keys continues
+func (db *Database) SavePublishState(ctx context.Context, authority_id []byte, cursor string, published_until time.Time) error {
+	return db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		_, err := txn.Exec(ctx, `INSERT INTO publish_state(authority_id, upload_cursor, published_until) VALUES ($1, $2, $3)
+								 ON CONFLICT (authority_id) DO UPDATE SET upload_cursor = $2, published_until = $3`,
+			authority_id, cursor, published_until.UTC())
+		if err != nil {
+			return fmt.Errorf("Could not save publish state: %w", err)
+		}
+		return nil
+	})
+}
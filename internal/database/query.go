@@ -13,52 +13,90 @@ import (
 // there is at least one filter defined in 'request'
 func buildQuery(request *proto.GetKeyRequest) (string, []interface{}, error) {
 	var query_values []interface{}
-	var query = `SELECT TEK, ENIN FROM reported_keys WHERE `
+	var query = `SELECT TEK, ENIN, rolling_period, transmission_risk_level, report_type, days_since_onset_of_symptoms
+				 FROM reported_keys`
 	var clauses []string
-	var suffix string
-	var err error
+	var joins string
 
 	// if health authority identifier is provided...
 	if len(request.AuthorityId) > 0 {
 		query_values = append(query_values, request.AuthorityId)
 		clauses = append(clauses, fmt.Sprintf("authority_id = $%d", len(query_values)))
-		suffix += `JOIN authorization_keys USING (authorization_key)
-			       JOIN health_authorities USING (api_key)`
+		joins += `JOIN authorization_keys USING (authorization_key)
+			      JOIN health_authorities USING (api_key)`
 	}
 
+	// if a cursor is provided, only return keys uploaded by transactions that had not
+	// completed when the cursor was handed out
+	if len(request.Since) > 0 {
+		since, err := decodeCursor(request.Since)
+		if err != nil {
+			return query, query_values, err
+		}
+		query_values = append(query_values, since)
+		clauses = append(clauses, fmt.Sprintf("upload_xid >= $%d", len(query_values)))
+	}
+
+	ranges, err := requestRanges(request)
+	if err != nil {
+		return query, query_values, err
+	}
+	for _, r := range ranges {
+		query_values = append(query_values, r.start)
+		clauses = append(clauses, fmt.Sprintf("enin >= $%d", len(query_values)))
+		query_values = append(query_values, r.end)
+		clauses = append(clauses, fmt.Sprintf("enin <= $%d", len(query_values)))
+	}
+
+	query = fmt.Sprintf("%s %s WHERE %s", query, joins, strings.Join(clauses, " AND "))
+	return query, query_values, nil
+}
+
+// inclusive range of ENIN timestamps
+type eninRange struct {
+	start, end time.Time
+}
+
+func (r eninRange) contains(ts time.Time) bool {
+	return timestampInRange(ts, r.start, r.end)
+}
+
+// returns the ENIN ranges selected by the request. Keys must fall into all of them
+func requestRanges(request *proto.GetKeyRequest) ([]eninRange, error) {
+	var ranges []eninRange
+
 	// if an ENIN is provided, round to the nearest day and default to [ENIN, ENIN + 1 day]
 	if request.ENIN > 0 {
 		start := eninToTimestamp(request.ENIN)
-		end := start.Add(24 * time.Hour)
-		query_values = append(query_values, start)
-		clauses = append(clauses, fmt.Sprintf("enin >= $%d", len(query_values)))
-		query_values = append(query_values, end)
-		clauses = append(clauses, fmt.Sprintf("enin <= $%d", len(query_values)))
+		ranges = append(ranges, eninRange{start, start.Add(24 * time.Hour)})
 	}
 
 	// if historical range [start, end] dates are provided, use that range.
 	// if historical range [days] is provided, generate filter for the last N days
 	// starting at [start_date]
-	if len(request.Hrange.StartDate) > 0 || request.Hrange.Days > 0 {
+	if request.Hrange != nil && (len(request.Hrange.StartDate) > 0 || request.Hrange.Days > 0) {
 		// default to current date if start_date not defined
-		var start, end time.Time
+		var end time.Time
+		var err error
 		if len(request.Hrange.StartDate) == 0 {
 			end = time.Now()
 		} else {
 			end, err = time.Parse(time.RFC3339, request.Hrange.StartDate)
 			if err != nil {
-				return query, query_values, err
+				return nil, err
 			}
 		}
 		end = end.UTC().Truncate(24 * time.Hour)
 		num_days := max(request.Hrange.Days, 1)
-		start = end.Add(time.Duration(num_days) * -24 * time.Hour)
-		query_values = append(query_values, start)
-		clauses = append(clauses, fmt.Sprintf("enin >= $%d", len(query_values)))
-		query_values = append(query_values, end)
-		clauses = append(clauses, fmt.Sprintf("enin <= $%d", len(query_values)))
+		start := end.Add(time.Duration(num_days) * -24 * time.Hour)
+		ranges = append(ranges, eninRange{start, end})
 	}
+	return ranges, nil
+}
 
-	query = fmt.Sprintf("%s %s %s", query, strings.Join(clauses, " AND "), suffix)
-	return query, query_values, nil
+// restricts a query built by buildQuery to keys uploaded by transactions that completed
+// before the given horizon, so that the cursor for the horizon is stable
+func withUploadHorizon(query string, query_values []interface{}, horizon int64) (string, []interface{}) {
+	query_values = append(query_values, horizon)
+	return fmt.Sprintf("%s AND upload_xid < $%d", query, len(query_values)), query_values
 }
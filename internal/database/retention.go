@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/logging"
+	"github.com/jackc/pgx/v4"
+)
+
+// arbitrary constant identifying the retention purge among postgres advisory locks
+const retentionLockID = 0x636f6d6d6f6e73
+
+// returned from the purge transaction to roll back a dry run
+var errDryRun = errors.New("dry run")
+
+// RetentionPolicy describes how long data is kept before PurgeExpired deletes it
+type RetentionPolicy struct {
+	// how long reported keys (by ENIN) and authorization keys (by issuance) are kept
+	Period time.Duration
+	// overrides Period for individual health authorities, keyed by hex-encoded authority_id
+	AuthorityPeriods map[string]time.Duration
+	// count what would be deleted, but roll back instead of committing
+	DryRun bool
+}
+
+// PurgeResult counts the rows deleted (or, for a dry run, that would have been deleted)
+type PurgeResult struct {
+	ReportedKeys      int64
+	AuthorizationKeys int64
+	VerificationCodes int64
+	// true if another replica was already purging and nothing was done
+	Skipped bool
+}
+
+// restricts a purge to the authorization keys of some health authorities
+type retentionScope struct {
+	cutoff time.Time
+	// SQL predicate on an authorization_key column; $2 is arg
+	predicate string
+	arg       interface{}
+}
+
+// Deletes reported keys whose ENIN is older than the retention period, then authorization
+// keys that were issued before the retention period and no longer have any reported keys,
+// along with expired verification codes. Only one replica purges at a time; the others
+// skip the run.
+func (db *Database) PurgeExpired(ctx context.Context, policy RetentionPolicy) (*PurgeResult, error) {
+	if policy.Period <= 0 {
+		return nil, errors.New("Retention period must be positive")
+	}
+	log := logging.FromContext(ctx)
+	now := time.Now().UTC()
+
+	// every authority with its own retention period gets its own pass; the last pass
+	// covers everything else with the default period
+	var scopes []retentionScope
+	var overridden [][]byte
+	for authority, period := range policy.AuthorityPeriods {
+		authority_id, err := hex.DecodeString(authority)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid authority_id %q in retention policy: %w", authority, err)
+		}
+		overridden = append(overridden, authority_id)
+		scopes = append(scopes, retentionScope{
+			cutoff: now.Add(-period),
+			predicate: `authorization_key IN (SELECT authorization_key FROM authorization_keys
+						JOIN health_authorities USING (api_key) WHERE authority_id = $2)`,
+			arg: authority_id,
+		})
+	}
+	scopes = append(scopes, retentionScope{
+		cutoff: now.Add(-policy.Period),
+		predicate: `authorization_key NOT IN (SELECT authorization_key FROM authorization_keys
+					JOIN health_authorities USING (api_key) WHERE authority_id = ANY($2))`,
+		arg: overridden,
+	})
+
+	result := &PurgeResult{}
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		var locked bool
+		if err := txn.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock($1)`, retentionLockID).Scan(&locked); err != nil {
+			return fmt.Errorf("Could not take retention lock: %w", err)
+		} else if !locked {
+			result.Skipped = true
+			return nil
+		}
+
+		for _, scope := range scopes {
+			tag, err := txn.Exec(ctx, `DELETE FROM reported_keys WHERE ENIN < $1 AND `+scope.predicate, scope.cutoff, scope.arg)
+			if err != nil {
+				return fmt.Errorf("Could not purge reported keys: %w", err)
+			}
+			result.ReportedKeys += tag.RowsAffected()
+
+			// authorization keys can only be removed once nothing references them
+			purgeable := `issued_at < $1 AND ` + scope.predicate + ` AND NOT EXISTS
+						  (SELECT 1 FROM reported_keys WHERE reported_keys.authorization_key = authorization_keys.authorization_key)`
+			tag, err = txn.Exec(ctx, `DELETE FROM verification_codes WHERE authorization_key IN
+									  (SELECT authorization_key FROM authorization_keys WHERE `+purgeable+`)`, scope.cutoff, scope.arg)
+			if err != nil {
+				return fmt.Errorf("Could not purge verification codes: %w", err)
+			}
+			result.VerificationCodes += tag.RowsAffected()
+
+			tag, err = txn.Exec(ctx, `DELETE FROM authorization_keys WHERE `+purgeable, scope.cutoff, scope.arg)
+			if err != nil {
+				return fmt.Errorf("Could not purge authorization keys: %w", err)
+			}
+			result.AuthorizationKeys += tag.RowsAffected()
+		}
+
+		tag, err := txn.Exec(ctx, `DELETE FROM verification_codes WHERE expires_at < $1`, now)
+		if err != nil {
+			return fmt.Errorf("Could not purge expired verification codes: %w", err)
+		}
+		result.VerificationCodes += tag.RowsAffected()
+
+		if policy.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	if !result.Skipped {
+		log.Debugf("Purged %d reported keys, %d authorization keys and %d verification codes (dry run: %t)",
+			result.ReportedKeys, result.AuthorizationKeys, result.VerificationCodes, policy.DryRun)
+	}
+	return result, nil
+}
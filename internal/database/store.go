@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/covista/commons/proto"
+)
+
+// Store holds the authorization keys and diagnosis keys behind the DiagnosisDB service.
+// Database is the postgres implementation; MemoryStore keeps everything in memory.
+type Store interface {
+	// Look up the health authority an active api_key belongs to; returns ErrInvalidApiKey if
+	// the key is unknown, revoked or belongs to a disabled authority
+	AuthenticateApiKey(ctx context.Context, api_key []byte) (*proto.Authority, error)
+	// Look up the health authority a verified client certificate belongs to, along with its
+	// newest active api_key, under which the authorization keys it creates are recorded;
+	// returns ErrInvalidClientAuthority if there is no such authority or api_key
+	AuthenticateClientAuthority(ctx context.Context, authority_id []byte) (*proto.Authority, []byte, error)
+	// Create a one-time use authorization key to be given to a patient
+	CreateAuthorizationKey(ctx context.Context, request *proto.TokenRequest) (*AuthorizationKey, error)
+	// Exchange a verification code for the authorization key it was issued with
+	ExchangeVerificationCode(ctx context.Context, request *proto.VerificationCodeRequest) ([]byte, error)
+	// Redeem the report's authorization key and store its diagnosis keys
+	AddReport(ctx context.Context, report *proto.Report) error
+	// Stream the diagnosis keys matching the request; the error channel is closed when done
+	GetDiagnosisKeys(ctx context.Context, request *proto.GetKeyRequest) (chan *proto.GetDiagnosisKeyResponse, chan error)
+	Close()
+}
+
+// AdminStore is implemented by stores that can manage health authorities
+type AdminStore interface {
+	CreateAuthority(ctx context.Context, request *proto.CreateAuthorityRequest) (*proto.Authority, []byte, error)
+	ListAuthorities(ctx context.Context, request *proto.ListAuthoritiesRequest) ([]*proto.Authority, error)
+	RenameAuthority(ctx context.Context, request *proto.RenameAuthorityRequest) (*proto.Authority, error)
+	DisableAuthority(ctx context.Context, request *proto.DisableAuthorityRequest) (*proto.Authority, error)
+	DeleteAuthority(ctx context.Context, request *proto.DeleteAuthorityRequest) error
+	IssueApiKey(ctx context.Context, request *proto.IssueApiKeyRequest) ([]byte, error)
+	RevokeApiKey(ctx context.Context, request *proto.RevokeApiKeyRequest) error
+}
+
+// RetentionStore is implemented by stores that can purge data past its retention period
+type RetentionStore interface {
+	PurgeExpired(ctx context.Context, policy RetentionPolicy) (*PurgeResult, error)
+}
+
+// PublishStore is implemented by stores that can keep replicas from publishing at the same
+// time, and remember where publishing left off
+type PublishStore interface {
+	WithPublishLock(ctx context.Context, f func(ctx context.Context) error) (bool, error)
+	PublishState(ctx context.Context, authority_id []byte) (string, time.Time, error)
+	SavePublishState(ctx context.Context, authority_id []byte, cursor string, published_until time.Time) error
+}
+
+// HealthStore is implemented by stores that can tell whether they are able to serve
+type HealthStore interface {
+	CheckHealth(ctx context.Context) error
+}
+
+var (
+	_ Store          = (*Database)(nil)
+	_ AdminStore     = (*Database)(nil)
+	_ RetentionStore = (*Database)(nil)
+	_ PublishStore   = (*Database)(nil)
+	_ HealthStore    = (*Database)(nil)
+	_ Store          = (*MemoryStore)(nil)
+	_ AdminStore     = (*MemoryStore)(nil)
+	_ RetentionStore = (*MemoryStore)(nil)
+	_ PublishStore   = (*MemoryStore)(nil)
+	_ HealthStore    = (*MemoryStore)(nil)
+	_ Store          = (*KeyCache)(nil)
+	_ RetentionStore = (*KeyCache)(nil)
+)
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/covista/commons/internal/tracing"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+)
+
+// statements longer than this are cut short in span attributes
+const maxTracedStatementLength = 500
+
+// a transaction that runs every statement in a span, as a child of the transaction's span
+type tracedTx struct {
+	pgx.Tx
+	span *tracing.Span
+}
+
+func (t *tracedTx) startSpan(ctx context.Context, name, sql string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.StartSpan(tracing.ContextWithSpan(ctx, t.span), name)
+	if span != nil {
+		span.SetAttribute("db.statement", compactStatement(sql))
+	}
+	return ctx, span
+}
+
+func (t *tracedTx) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := t.startSpan(ctx, "sql.Exec", sql)
+	tag, err := t.Tx.Exec(ctx, sql, args...)
+	span.SetAttribute("db.rows_affected", tag.RowsAffected())
+	span.SetError(err)
+	span.End()
+	return tag, err
+}
+
+func (t *tracedTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := t.startSpan(ctx, "sql.Query", sql)
+	rows, err := t.Tx.Query(ctx, sql, args...)
+	if err != nil || span == nil {
+		span.SetError(err)
+		span.End()
+		return rows, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t *tracedTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	ctx, span := t.startSpan(ctx, "sql.QueryRow", sql)
+	return &tracedRow{row: t.Tx.QueryRow(ctx, sql, args...), span: span}
+}
+
+// ends the query's span once the rows are read or closed, which includes the time callers
+// spend between rows
+type tracedRows struct {
+	pgx.Rows
+	span  *tracing.Span
+	count int
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	r.finish()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *tracedRows) finish() {
+	r.span.SetAttribute("db.rows", r.count)
+	r.span.SetError(r.Rows.Err())
+	r.span.End()
+}
+
+type tracedRow struct {
+	row  pgx.Row
+	span *tracing.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		r.span.SetError(err)
+	}
+	r.span.End()
+	return err
+}
+
+// collapses the whitespace of a statement, so that it reads well on one line
+func compactStatement(sql string) string {
+	compact := strings.Join(strings.Fields(sql), " ")
+	if len(compact) > maxTracedStatementLength {
+		compact = compact[:maxTracedStatementLength] + "..."
+	}
+	return compact
+}
@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/proto"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	// with 8 digits, even a million active codes leave a guess a 1% chance
+	minVerificationCodeLength = 8
+	maxVerificationCodeLength = 10
+	// how many times to regenerate a code that collides with an active one
+	maxVerificationCodeAttempts = 10
+)
+
+// returns a random string of the given number of decimal digits
+func generateVerificationCode(length uint32) (string, error) {
+	code := make([]byte, length)
+	for idx := range code {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		code[idx] = byte('0' + digit.Int64())
+	}
+	return string(code), nil
+}
+
+// generates a verification code for the authorization key and stores it. Codes are unique
+// among active codes; codes that expired or were already exchanged are replaced
+func createVerificationCode(ctx context.Context, txn pgx.Tx, authorization_key []byte, length uint32, ttl time.Duration) (string, time.Time, error) {
+	expires_at := time.Now().UTC().Add(ttl)
+	for attempt := 0; attempt < maxVerificationCodeAttempts; attempt++ {
+		code, err := generateVerificationCode(length)
+		if err != nil {
+			return "", expires_at, fmt.Errorf("Could not generate verification code: %w", err)
+		}
+		_, err = txn.Exec(ctx, `DELETE FROM verification_codes
+								WHERE code = $1 AND (expires_at <= $2 OR exchanged_at IS NOT NULL)`, code, time.Now().UTC())
+		if err != nil {
+			return "", expires_at, fmt.Errorf("Could not remove stale verification code: %w", err)
+		}
+		tag, err := txn.Exec(ctx, `INSERT INTO verification_codes(code, authorization_key, expires_at)
+								   VALUES ($1, $2, $3) ON CONFLICT (code) DO NOTHING`, code, authorization_key, expires_at)
+		if err != nil {
+			return "", expires_at, fmt.Errorf("Could not insert verification code: %w", err)
+		} else if tag.RowsAffected() == 1 {
+			verificationCodesCreated.Inc()
+			return code, expires_at, nil
+		}
+	}
+	return "", expires_at, errors.New("Could not generate a unique verification code; try a longer code")
+}
+
+// Exchanges a verification code for the authorization key it was issued with. A code can
+// only be exchanged once, and only before it expires or is burned. A failed exchange only
+// counts against the code it named, if that exists; guesses are limited per client by the
+// server instead, and codes are long enough that guessing any active one is unlikely
+func (db *Database) ExchangeVerificationCode(ctx context.Context, request *proto.VerificationCodeRequest) ([]byte, error) {
+	verificationCodeExchangeAttempts.Inc()
+	if err := checkVerificationCodeRequest(request); err != nil {
+		return nil, fmt.Errorf("Invalid VerificationCodeRequest: %w", err)
+	}
+	log := logging.FromContext(ctx)
+
+	var authorization_key []byte
+	err := db.RunAsTransaction(ctx, func(txn pgx.Tx) error {
+		now := time.Now().UTC()
+		err := txn.QueryRow(ctx, `UPDATE verification_codes SET exchanged_at = $2
+								  WHERE code = $1 AND exchanged_at IS NULL AND expires_at > $2 AND failed_attempts < $3
+								  RETURNING authorization_key`, request.VerificationCode, now, db.lifetimes.verificationFailures).Scan(&authorization_key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// committed, so that the failure counts even though the exchange failed
+			var failed_attempts int
+			err = txn.QueryRow(ctx, `UPDATE verification_codes SET failed_attempts = failed_attempts + 1
+									 WHERE code = $1 RETURNING failed_attempts`, request.VerificationCode).Scan(&failed_attempts)
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("Could not record failed verification code: %w", err)
+			}
+			if failed_attempts == db.lifetimes.verificationFailures {
+				log.Warnf("Burned verification code after %d failed exchanges", failed_attempts)
+				verificationCodesBurned.Inc()
+			}
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("Could not exchange verification code: %w", err)
+		}
+		log.Infof("Exchanged verification code for auth key %x", authorization_key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	} else if authorization_key == nil {
+		return nil, ErrInvalidVerificationCode
+	}
+	verificationCodesExchanged.Inc()
+	return authorization_key, nil
+}
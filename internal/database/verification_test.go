@@ -0,0 +1,95 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/proto"
+)
+
+// returns a code of the same length that differs from code in every digit
+func wrongCode(code string) string {
+	wrong := []byte(code)
+	for idx := range wrong {
+		wrong[idx] = '0' + (wrong[idx]-'0'+1)%10
+	}
+	return string(wrong)
+}
+
+func TestExchangeVerificationCode(t *testing.T) {
+	ctx := context.Background()
+	store, api_key := newTestStore(t, &config.Config{})
+	key, err := store.CreateAuthorizationKey(ctx, testTokenRequest(api_key, 8))
+	if err != nil {
+		t.Fatalf("could not create authorization key: %v", err)
+	} else if len(key.VerificationCode) != 8 {
+		t.Fatalf("expected an 8-digit verification code, got %q", key.VerificationCode)
+	}
+
+	exchange := func(code string) ([]byte, error) {
+		return store.ExchangeVerificationCode(ctx, &proto.VerificationCodeRequest{VerificationCode: code})
+	}
+	if _, err := exchange(wrongCode(key.VerificationCode)); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Fatalf("expected ErrInvalidVerificationCode for a wrong code, got %v", err)
+	}
+	exchanged, err := exchange(key.VerificationCode)
+	if err != nil {
+		t.Fatalf("could not exchange code: %v", err)
+	} else if !bytes.Equal(exchanged, key.Key) {
+		t.Fatalf("exchanged %x, expected %x", exchanged, key.Key)
+	}
+	if _, err := exchange(key.VerificationCode); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Fatalf("expected ErrInvalidVerificationCode for an exchanged code, got %v", err)
+	}
+}
+
+func TestWrongCodesOnlyCountAgainstTheirCode(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{}
+	cfg.Authorization.MaxVerificationFailures = 1
+	store, api_key := newTestStore(t, cfg)
+	var codes []string
+	for i := 0; i < 3; i++ {
+		key, err := store.CreateAuthorizationKey(ctx, testTokenRequest(api_key, 8))
+		if err != nil {
+			t.Fatalf("could not create authorization key: %v", err)
+		}
+		codes = append(codes, key.VerificationCode)
+	}
+	exchange := func(code string) error {
+		_, err := store.ExchangeVerificationCode(ctx, &proto.VerificationCodeRequest{VerificationCode: code})
+		return err
+	}
+
+	miss := wrongCode(codes[0])
+	for miss == codes[1] || miss == codes[2] {
+		miss = wrongCode(miss)
+	}
+	// many more misses than any code survives
+	for i := 0; i < 10; i++ {
+		if err := exchange(miss); !errors.Is(err, ErrInvalidVerificationCode) {
+			t.Fatalf("expected ErrInvalidVerificationCode for a wrong code, got %v", err)
+		}
+	}
+	if err := exchange(codes[0]); err != nil {
+		t.Fatalf("could not exchange a code after misses on other codes: %v", err)
+	}
+	// replaying an exchanged code burns only that code
+	if err := exchange(codes[0]); !errors.Is(err, ErrInvalidVerificationCode) {
+		t.Fatalf("expected ErrInvalidVerificationCode for a replayed code, got %v", err)
+	}
+	store.Lock()
+	failed := []int{store.verification_codes[codes[0]].failed_attempts, store.verification_codes[codes[1]].failed_attempts, store.verification_codes[codes[2]].failed_attempts}
+	store.Unlock()
+	if failed[0] != 1 || failed[1] != 0 || failed[2] != 0 {
+		t.Fatalf("expected only the replayed code to count a failure, got %v", failed)
+	}
+	for _, code := range codes[1:] {
+		if err := exchange(code); err != nil {
+			t.Fatalf("could not exchange code: %v", err)
+		}
+	}
+}
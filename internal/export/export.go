@@ -0,0 +1,360 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/signing"
+	"github.com/covista/commons/proto"
+	"github.com/covista/commons/proto/export"
+	pb "github.com/golang/protobuf/proto"
+)
+
+const (
+	// every export.bin starts with this 16-byte header, padded with spaces
+	exportHeader = "EK Export v1    "
+	// names of the files inside of each export archive
+	exportBinName = "export.bin"
+	exportSigName = "export.sig"
+	// upper bound on the number of keys in a single export file recommended
+	// by the Exposure Notification API
+	defaultMaxKeysPerFile = 750000
+)
+
+// returned when an authority has no signing keys configured
+var ErrNoSigningKeys = errors.New("No signing keys configured")
+
+func checkConfig(cfg *config.Config) error {
+	return config.Check(cfg, config.ExportSection)
+}
+
+// Batch is one file of an export: the keys for a single day and health authority,
+// split into BatchSize files of at most MaxKeysPerFile keys each.
+type Batch struct {
+	Region         string
+	StartTimestamp time.Time
+	EndTimestamp   time.Time
+	// ordinal, 1-based number of this file in the batch
+	BatchNum  int
+	BatchSize int
+	Keys      []*proto.TimestampedTEK
+	// each signer adds a signature to export.sig
+	Signers []signing.Signer
+}
+
+// Exporter turns the diagnosis keys stored in the database into export archives
+// that can be handed to the Exposure Notification API on the phones
+type Exporter struct {
+	db             database.Store
+	keys           signing.KeyProvider
+	maxKeysPerFile int
+}
+
+// Creates a new Exporter reading keys from the given database and signing
+// with the keys listed in the configuration
+func NewFromConfig(db database.Store, cfg *config.Config) (*Exporter, error) {
+	if err := checkConfig(cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config for exporter: %w", err)
+	}
+	keys, err := signing.NewFileKeyProviderFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("Could not load signing keys: %w", err)
+	}
+	return NewWithKeyProvider(db, keys, cfg.Export.MaxKeysPerFile), nil
+}
+
+// Creates a new Exporter signing with the given key provider. A maxKeysPerFile of 0
+// uses the default
+func NewWithKeyProvider(db database.Store, keys signing.KeyProvider, maxKeysPerFile int) *Exporter {
+	if maxKeysPerFile <= 0 {
+		maxKeysPerFile = defaultMaxKeysPerFile
+	}
+	return &Exporter{
+		db:             db,
+		keys:           keys,
+		maxKeysPerFile: maxKeysPerFile,
+	}
+}
+
+// Returns the batches containing all keys reported through the given health authority
+// whose ENIN falls on the given (UTC) day. There is always at least one batch, even if
+// there were no keys reported, so that clients can tell an empty day from a missing one
+func (exp *Exporter) ExportDay(ctx context.Context, authorityID []byte, day time.Time) ([]*Batch, error) {
+	if len(authorityID) == 0 {
+		return nil, errors.New("Export requires an authority_id")
+	}
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.Add(24 * time.Hour)
+
+	log := logging.FromContext(ctx)
+	log.Infof("Exporting keys for authority %x on %s", authorityID, start.Format("2006-01-02"))
+
+	signers, err := exp.signers(authorityID)
+	if err != nil {
+		return nil, err
+	}
+
+	results, errchan := exp.db.GetDiagnosisKeys(ctx, &proto.GetKeyRequest{
+		AuthorityId: authorityID,
+		ENIN:        timestampToENIN(start),
+	})
+	keys, _, err := collectKeys(ctx, results, errchan)
+	if err != nil {
+		return nil, fmt.Errorf("Could not fetch keys for export: %w", err)
+	}
+
+	// the database query includes the first ENIN of the following day
+	var filtered []*proto.TimestampedTEK
+	for _, key := range keys {
+		if key.ENIN < timestampToENIN(end) {
+			filtered = append(filtered, key)
+		}
+	}
+	exportKeysTotal.Add(float64(len(filtered)))
+
+	batches := NewBatches(hex.EncodeToString(authorityID), start, end, filtered, exp.maxKeysPerFile)
+	for _, batch := range batches {
+		batch.Signers = signers
+	}
+	return batches, nil
+}
+
+// Returns the batches containing the keys reported through the given health authority since
+// the download that returned the cursor, or all of its keys for an empty cursor, along with
+// the cursor for the next call. The batches cover the time from start to end, in which the
+// keys were uploaded. There are no batches if no keys were reported
+func (exp *Exporter) ExportSince(ctx context.Context, authorityID []byte, cursor string, start, end time.Time) ([]*Batch, string, error) {
+	if len(authorityID) == 0 {
+		return nil, "", errors.New("Export requires an authority_id")
+	}
+	signers, err := exp.signers(authorityID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	results, errchan := exp.db.GetDiagnosisKeys(ctx, &proto.GetKeyRequest{
+		AuthorityId: authorityID,
+		Since:       cursor,
+		Resumable:   true,
+	})
+	keys, next, err := collectKeys(ctx, results, errchan)
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not fetch keys for export: %w", err)
+	} else if len(next) == 0 {
+		return nil, "", errors.New("Store did not return a cursor")
+	} else if len(keys) == 0 {
+		return nil, next, nil
+	}
+	exportKeysTotal.Add(float64(len(keys)))
+
+	batches := NewBatches(hex.EncodeToString(authorityID), start.UTC(), end.UTC(), keys, exp.maxKeysPerFile)
+	for _, batch := range batches {
+		batch.Signers = signers
+	}
+	return batches, next, nil
+}
+
+// returns the keys signing the authority's exports. Clients reject unsigned exports, so an
+// authority without keys cannot be exported
+func (exp *Exporter) signers(authorityID []byte) ([]signing.Signer, error) {
+	signers, err := exp.keys.Signers(authorityID)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get signing keys for authority %x: %w", authorityID, err)
+	} else if len(signers) == 0 {
+		return nil, fmt.Errorf("%w for authority %x", ErrNoSigningKeys, authorityID)
+	}
+	return signers, nil
+}
+
+// Splits the keys into batches of at most maxKeys keys each. The keys are sorted by their
+// key data so that the position of a key in the export does not reveal when it was uploaded.
+func NewBatches(region string, start, end time.Time, keys []*proto.TimestampedTEK, maxKeys int) []*Batch {
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeysPerFile
+	}
+	sorted := make([]*proto.TimestampedTEK, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].TEK, sorted[j].TEK) < 0
+	})
+
+	size := (len(sorted) + maxKeys - 1) / maxKeys
+	if size == 0 {
+		size = 1
+	}
+	batches := make([]*Batch, size)
+	for idx := range batches {
+		lo := idx * maxKeys
+		hi := lo + maxKeys
+		if hi > len(sorted) {
+			hi = len(sorted)
+		}
+		batches[idx] = &Batch{
+			Region:         region,
+			StartTimestamp: start,
+			EndTimestamp:   end,
+			BatchNum:       idx + 1,
+			BatchSize:      size,
+			Keys:           sorted[lo:hi],
+		}
+	}
+	exportBatchesTotal.Add(float64(size))
+	return batches
+}
+
+// Returns the name of the archive for this batch, e.g. 1589500800-1589587200-00001.zip
+func (b *Batch) Filename() string {
+	return fmt.Sprintf("%d-%d-%05d.zip", b.StartTimestamp.Unix(), b.EndTimestamp.Unix(), b.BatchNum)
+}
+
+// Returns the end of the time range of an archive named by Filename
+func FilenameEnd(name string) (time.Time, error) {
+	var start, end int64
+	var num int
+	if _, err := fmt.Sscanf(name, "%d-%d-%05d.zip", &start, &end, &num); err != nil {
+		return time.Time{}, fmt.Errorf("%s is not the name of an export archive", name)
+	}
+	return time.Unix(end, 0).UTC(), nil
+}
+
+// Returns the protobuf message stored in export.bin
+func (b *Batch) Proto() *export.TemporaryExposureKeyExport {
+	msg := &export.TemporaryExposureKeyExport{
+		StartTimestamp: pb.Uint64(uint64(b.StartTimestamp.Unix())),
+		EndTimestamp:   pb.Uint64(uint64(b.EndTimestamp.Unix())),
+		Region:         pb.String(b.Region),
+		BatchNum:       pb.Int32(int32(b.BatchNum)),
+		BatchSize:      pb.Int32(int32(b.BatchSize)),
+	}
+	for _, signer := range b.Signers {
+		msg.SignatureInfos = append(msg.SignatureInfos, signer.SignatureInfo())
+	}
+	for _, key := range b.Keys {
+		tek := &export.TemporaryExposureKey{
+			KeyData:                    key.TEK,
+			RollingStartIntervalNumber: pb.Int32(int32(key.ENIN)),
+			TransmissionRiskLevel:      pb.Int32(key.TransmissionRiskLevel),
+			ReportType:                 export.TemporaryExposureKey_ReportType(key.ReportType).Enum(),
+		}
+		if key.RollingPeriod > 0 {
+			tek.RollingPeriod = pb.Int32(int32(key.RollingPeriod))
+		}
+		if key.DaysSinceOnsetOfSymptoms != nil {
+			tek.DaysSinceOnsetOfSymptoms = pb.Int32(key.DaysSinceOnsetOfSymptoms.Value)
+		}
+		msg.Keys = append(msg.Keys, tek)
+	}
+	return msg
+}
+
+// Returns the contents of export.bin: the export header followed by the serialized
+// TemporaryExposureKeyExport
+func (b *Batch) MarshalExport() ([]byte, error) {
+	body, err := pb.Marshal(b.Proto())
+	if err != nil {
+		return nil, fmt.Errorf("Could not serialize export: %w", err)
+	}
+	return append([]byte(exportHeader), body...), nil
+}
+
+// Returns the contents of export.sig: one signature over export.bin per signer
+func (b *Batch) MarshalSignatures(bin []byte) ([]byte, error) {
+	list := &export.TEKSignatureList{}
+	for _, signer := range b.Signers {
+		sig, err := signer.Sign(bin)
+		if err != nil {
+			return nil, err
+		}
+		list.Signatures = append(list.Signatures, &export.TEKSignature{
+			SignatureInfo: signer.SignatureInfo(),
+			BatchNum:      pb.Int32(int32(b.BatchNum)),
+			BatchSize:     pb.Int32(int32(b.BatchSize)),
+			Signature:     sig,
+		})
+	}
+	exportSignaturesTotal.Add(float64(len(list.Signatures)))
+	out, err := pb.Marshal(list)
+	if err != nil {
+		return nil, fmt.Errorf("Could not serialize signature list: %w", err)
+	}
+	return out, nil
+}
+
+// Writes the zip archive containing export.bin and export.sig for this batch
+func (b *Batch) WriteArchive(w io.Writer) error {
+	bin, err := b.MarshalExport()
+	if err != nil {
+		return err
+	}
+	sig, err := b.MarshalSignatures(bin)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range []struct {
+		name     string
+		contents []byte
+	}{
+		{exportBinName, bin},
+		{exportSigName, sig},
+	} {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("Could not create %s in archive: %w", file.name, err)
+		}
+		if _, err := f.Write(file.contents); err != nil {
+			return fmt.Errorf("Could not write %s to archive: %w", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("Could not finish archive: %w", err)
+	}
+	return nil
+}
+
+// drains the channels returned by Store.GetDiagnosisKeys. Also returns the cursor of
+// resumable downloads
+func collectKeys(ctx context.Context, results chan *proto.GetDiagnosisKeyResponse, errchan chan error) ([]*proto.TimestampedTEK, string, error) {
+	var (
+		keys   []*proto.TimestampedTEK
+		cursor string
+	)
+	for {
+		select {
+		case err := <-errchan:
+			if err != nil {
+				return nil, "", err
+			}
+			// errchan is closed after results, so everything has been received
+			return keys, cursor, nil
+		case resp, ok := <-results:
+			if !ok {
+				results = nil
+				continue
+			}
+			if resp.Record != nil {
+				keys = append(keys, resp.Record)
+			}
+			if len(resp.NextCursor) > 0 {
+				cursor = resp.NextCursor
+			}
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+}
+
+func timestampToENIN(ts time.Time) uint32 {
+	return uint32(ts.Unix() / 600)
+}
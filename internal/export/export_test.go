@@ -0,0 +1,246 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/signing"
+	"github.com/covista/commons/proto"
+	"github.com/covista/commons/proto/export"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// serves the same signers for every authority
+type staticKeys []signing.Signer
+
+func (k staticKeys) Signers(authorityID []byte) ([]signing.Signer, error) {
+	return k, nil
+}
+
+func testKeys(n int) []*proto.TimestampedTEK {
+	var keys []*proto.TimestampedTEK
+	for i := 0; i < n; i++ {
+		// reverse order, so that sorting shows
+		keys = append(keys, &proto.TimestampedTEK{TEK: bytes.Repeat([]byte{byte(n - i)}, 16), ENIN: 2650000})
+	}
+	return keys
+}
+
+func TestNewBatches(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		keys    int
+		maxKeys int
+		sizes   []int
+	}{
+		{"no keys", 0, 10, []int{0}},
+		{"one batch", 3, 10, []int{3}},
+		{"exactly full", 4, 2, []int{2, 2}},
+		{"partial last batch", 5, 2, []int{2, 2, 1}},
+		{"default size", 5, 0, []int{5}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := NewBatches("region", time.Unix(0, 0), time.Unix(86400, 0), testKeys(tt.keys), tt.maxKeys)
+			if len(batches) != len(tt.sizes) {
+				t.Fatalf("expected %d batches, got %d", len(tt.sizes), len(batches))
+			}
+			var previous []byte
+			for idx, batch := range batches {
+				if batch.BatchNum != idx+1 || batch.BatchSize != len(tt.sizes) {
+					t.Fatalf("batch %d is numbered %d of %d", idx, batch.BatchNum, batch.BatchSize)
+				} else if len(batch.Keys) != tt.sizes[idx] {
+					t.Fatalf("batch %d has %d keys, expected %d", idx, len(batch.Keys), tt.sizes[idx])
+				}
+				for _, key := range batch.Keys {
+					if bytes.Compare(previous, key.TEK) > 0 {
+						t.Fatalf("keys are not sorted: %x after %x", key.TEK, previous)
+					}
+					previous = key.TEK
+				}
+			}
+		})
+	}
+}
+
+// returns the contents of each file in the archive
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("could not read archive: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, file := range archive.File {
+		f, err := file.Open()
+		if err != nil {
+			t.Fatalf("could not open %s: %v", file.Name, err)
+		}
+		files[file.Name], _ = ioutil.ReadAll(f)
+		f.Close()
+	}
+	return files
+}
+
+func TestWriteArchive(t *testing.T) {
+	batch := NewBatches("region", time.Unix(1589500800, 0), time.Unix(1589587200, 0), testKeys(3), 2)[0]
+	var buf bytes.Buffer
+	if err := batch.WriteArchive(&buf); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+	if name := batch.Filename(); name != "1589500800-1589587200-00001.zip" {
+		t.Fatalf("unexpected file name %s", name)
+	}
+
+	bin := readArchive(t, buf.Bytes())[exportBinName]
+	if !bytes.HasPrefix(bin, []byte(exportHeader)) {
+		t.Fatalf("export.bin does not start with the header")
+	}
+	var msg export.TemporaryExposureKeyExport
+	if err := pb.Unmarshal(bin[len(exportHeader):], &msg); err != nil {
+		t.Fatalf("could not parse export.bin: %v", err)
+	} else if len(msg.Keys) != 2 || msg.GetBatchNum() != 1 || msg.GetBatchSize() != 2 || msg.GetRegion() != "region" {
+		t.Fatalf("unexpected export: %d keys, batch %d of %d, region %q", len(msg.Keys), msg.GetBatchNum(), msg.GetBatchSize(), msg.GetRegion())
+	}
+	if msg.GetStartTimestamp() != 1589500800 || msg.GetEndTimestamp() != 1589587200 {
+		t.Fatalf("export covers %d to %d", msg.GetStartTimestamp(), msg.GetEndTimestamp())
+	}
+}
+
+// a memory store with keys reported on the given days, and the authority they belong to
+func newTestStore(t *testing.T, days ...time.Time) (database.Store, []byte) {
+	t.Helper()
+	ctx := context.Background()
+	store, err := database.NewMemoryStore(&config.Config{})
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+	authority, api_key, err := store.CreateAuthority(ctx, &proto.CreateAuthorityRequest{Name: "Test Health Authority"})
+	if err != nil {
+		t.Fatalf("could not create authority: %v", err)
+	}
+	for idx, day := range days {
+		key, err := store.CreateAuthorizationKey(ctx, &proto.TokenRequest{
+			ApiKey:              api_key,
+			PermittedRangeStart: day.Add(-24 * time.Hour).Format(time.RFC3339),
+			PermittedRangeEnd:   day.Add(24 * time.Hour).Format(time.RFC3339),
+		})
+		if err != nil {
+			t.Fatalf("could not create authorization key: %v", err)
+		}
+		err = store.AddReport(ctx, &proto.Report{
+			AuthorizationKey: key.Key,
+			Reports:          []*proto.TimestampedTEK{{TEK: bytes.Repeat([]byte{byte(idx + 1)}, 16), ENIN: timestampToENIN(day)}},
+		})
+		if err != nil {
+			t.Fatalf("could not add report: %v", err)
+		}
+	}
+	return store, authority.AuthorityId
+}
+
+func TestExportDay(t *testing.T) {
+	day := time.Now().UTC().Add(-5 * 24 * time.Hour).Truncate(24 * time.Hour)
+	store, authorityID := newTestStore(t, day.Add(time.Hour), day.Add(2*time.Hour), day.Add(3*time.Hour), day.Add(24*time.Hour))
+	signer := newTestSigner(t)
+
+	for _, tt := range []struct {
+		name    string
+		keys    staticKeys
+		maxKeys int
+		sizes   []int
+		err     error
+	}{
+		{"one batch", staticKeys{signer}, 0, []int{3}, nil},
+		{"batched", staticKeys{signer}, 2, []int{2, 1}, nil},
+		{"no signing keys", nil, 0, nil, ErrNoSigningKeys},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := NewWithKeyProvider(store, tt.keys, tt.maxKeys)
+			batches, err := exporter.ExportDay(context.Background(), authorityID, day)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, got %v", tt.err, err)
+			} else if len(batches) != len(tt.sizes) {
+				t.Fatalf("expected %d batches, got %d", len(tt.sizes), len(batches))
+			}
+			for idx, batch := range batches {
+				if len(batch.Keys) != tt.sizes[idx] {
+					t.Fatalf("batch %d has %d keys, expected %d", idx, len(batch.Keys), tt.sizes[idx])
+				} else if !batch.StartTimestamp.Equal(day) || !batch.EndTimestamp.Equal(day.Add(24*time.Hour)) {
+					t.Fatalf("batch %d covers %s to %s", idx, batch.StartTimestamp, batch.EndTimestamp)
+				} else if len(batch.Signers) != 1 {
+					t.Fatalf("batch %d has %d signers", idx, len(batch.Signers))
+				}
+			}
+		})
+	}
+}
+
+type testSigner struct {
+	signing.Signer
+	key *ecdsa.PrivateKey
+}
+
+func newTestSigner(t *testing.T) testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	signer, err := signing.NewECDSASigner(key, "310", "v1")
+	if err != nil {
+		t.Fatalf("could not create signer: %v", err)
+	}
+	return testSigner{signer, key}
+}
+
+func TestWriteArchiveSignatures(t *testing.T) {
+	signers := []testSigner{newTestSigner(t), newTestSigner(t)}
+	batch := NewBatches("region", time.Unix(1589500800, 0), time.Unix(1589587200, 0), testKeys(3), 0)[0]
+	for _, signer := range signers {
+		batch.Signers = append(batch.Signers, signer)
+	}
+	var buf bytes.Buffer
+	if err := batch.WriteArchive(&buf); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+	files := readArchive(t, buf.Bytes())
+
+	bin := files[exportBinName]
+	var msg export.TemporaryExposureKeyExport
+	if err := pb.Unmarshal(bin[len(exportHeader):], &msg); err != nil {
+		t.Fatalf("could not parse export.bin: %v", err)
+	} else if len(msg.SignatureInfos) != len(signers) {
+		t.Fatalf("expected %d signature infos, got %d", len(signers), len(msg.SignatureInfos))
+	}
+	var list export.TEKSignatureList
+	if err := pb.Unmarshal(files[exportSigName], &list); err != nil {
+		t.Fatalf("could not parse export.sig: %v", err)
+	} else if len(list.Signatures) != len(signers) {
+		t.Fatalf("expected %d signatures, got %d", len(signers), len(list.Signatures))
+	}
+	// the signatures cover export.bin including its header
+	digest := sha256.Sum256(bin)
+	for idx, sig := range list.Signatures {
+		var parsed struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(sig.Signature, &parsed); err != nil {
+			t.Fatalf("signature %d is not ASN.1: %v", idx, err)
+		} else if !ecdsa.Verify(&signers[idx].key.PublicKey, digest[:], parsed.R, parsed.S) {
+			t.Fatalf("signature %d does not verify", idx)
+		} else if sig.SignatureInfo.GetSignatureAlgorithm() != signing.AlgorithmECDSAP256SHA256 {
+			t.Fatalf("signature %d has algorithm %s", idx, sig.SignatureInfo.GetSignatureAlgorithm())
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package export
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	exportKeysTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_export_keys_total",
+		Help: "Total number of diagnosis keys written to export batches",
+	})
+	exportBatchesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_export_batches_total",
+		Help: "Total number of export batch files generated",
+	})
+	exportSignaturesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_export_signatures_total",
+		Help: "Total number of signatures produced over export files",
+	})
+)
@@ -2,40 +2,133 @@ package logging
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sync"
 
+	"github.com/covista/commons/internal/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var logkey struct{}
-var logger *zap.SugaredLogger
+type logkeyType struct{}
+
+var logkey logkeyType
+
+var (
+	// shared by every logger, so that the level can be changed at runtime
+	level = zap.NewAtomicLevelAt(zap.InfoLevel)
+
+	loggerLock sync.RWMutex
+	logger     *zap.SugaredLogger
+)
 
 func init() {
+	base, err := build("console")
+	if err != nil {
+		log.Println("Could not build logger:", err)
+		base = zap.NewNop().Sugar()
+	}
+	logger = base
+}
+
+func build(encoding string) (*zap.SugaredLogger, error) {
 	config := zap.NewProductionConfig()
-	config.Encoding = "console"
+	config.Level = level
+	config.Encoding = encoding
 	config.EncoderConfig.EncodeTime = zapcore.RFC3339TimeEncoder
-	_logger, err := config.Build()
+	built, err := config.Build()
 	if err != nil {
-		log.Fatal("Could not build logger", err)
-		logger = zap.NewNop().Sugar()
-	} else {
-		logger = _logger.Named("commons").Sugar()
+		return nil, err
 	}
+	return built.Named("commons").Sugar(), nil
+}
+
+func checkConfig(cfg *config.Config) error {
+	return config.Check(cfg, config.LoggingSection)
+}
+
+func parseLevel(name string) (zapcore.Level, error) {
+	switch name {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "warn":
+		return zapcore.WarnLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	}
+	return zapcore.InfoLevel, fmt.Errorf("%q is not debug, info, warn or error", name)
+}
+
+// Applies the configured level and format to all loggers created from now on. Contexts
+// that already carry a logger keep it, but it follows level changes
+func Configure(cfg *config.Config) error {
+	if err := checkConfig(cfg); err != nil {
+		return fmt.Errorf("Invalid config for logging: %w", err)
+	}
+	format := cfg.Logging.Format
+	if len(format) == 0 {
+		format = "console"
+	}
+	if len(cfg.Logging.Level) > 0 {
+		if err := SetLevel(cfg.Logging.Level); err != nil {
+			return err
+		}
+	}
+	base, err := build(format)
+	if err != nil {
+		return fmt.Errorf("Could not build logger: %w", err)
+	}
+	loggerLock.Lock()
+	logger = base
+	loggerLock.Unlock()
+	return nil
+}
+
+// Changes the level of every logger
+func SetLevel(name string) error {
+	l, err := parseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.SetLevel(l)
+	return nil
+}
+
+// Returns the current level
+func Level() string {
+	return level.Level().String()
+}
+
+func base() *zap.SugaredLogger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+	return logger
 }
 
 func NewContextWithLogger() context.Context {
 	return WithLogger(context.Background())
 }
 
+// Attaches the base logger to the context, unless it already carries one
 func WithLogger(ctx context.Context) context.Context {
-	return context.WithValue(ctx, logkey, logger)
+	if _, ok := ctx.Value(logkey).(*zap.SugaredLogger); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, logkey, base())
+}
+
+// Attaches a logger that adds the given key-value pairs to every entry, e.g. a request ID
+func WithFields(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	return context.WithValue(ctx, logkey, FromContext(ctx).With(keysAndValues...))
 }
 
+// Returns the context's logger, or the base logger if it has none
 func FromContext(ctx context.Context) *zap.SugaredLogger {
 	if logger, ok := ctx.Value(logkey).(*zap.SugaredLogger); ok {
 		return logger
 	}
-	log.Fatal("bad")
-	return zap.L().Sugar()
+	return base()
 }
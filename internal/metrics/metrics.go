@@ -1,23 +1,61 @@
 package metrics
 
 import (
+	"context"
+	"net"
 	"net/http"
 
-	//"github.com/prometheus/client_golang/prometheus"
-	//"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/covista/commons/internal/config"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// func recordMetrics() {
-// 	go func() {
-// 		for {
-// 			opsProcessed.Inc()
-// 			time.Sleep(2 * time.Second)
-// 		}
-// 	}()
-// }
-
-func Serve() error {
-	http.Handle("/metrics", promhttp.Handler())
-	return http.ListenAndServe(":2112", nil)
+const defaultPort = "2112"
+
+// Returns the handler serving the Prometheus metrics, for mounting on another listener
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Server serves the Prometheus metrics on a listener of its own
+type Server struct {
+	httpServer *http.Server
+}
+
+// Creates a new Server for the configured metrics listener. Returns nil (and no error) if
+// the metrics are served on the HTTP gateway listener instead
+func NewFromConfig(cfg *config.Config) (*Server, error) {
+	if cfg.Metrics.OnHTTP {
+		return nil, nil
+	}
+	port := cfg.Metrics.Port
+	if len(port) == 0 {
+		port = defaultPort
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &Server{
+		httpServer: &http.Server{
+			Addr:    net.JoinHostPort(cfg.Metrics.ListenAddress, port),
+			Handler: mux,
+		},
+	}, nil
+}
+
+// Serves the metrics until Shutdown is called. Returns immediately for a nil Server
+func (s *Server) Serve() error {
+	if s == nil {
+		return nil
+	}
+	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stops serving, waiting for in-flight scrapes until the context expires
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	return s.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,163 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// downloads can stream for minutes, so the buckets reach further than the defaults
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+
+var (
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_grpc_requests",
+		Help: "Number of finished GRPC calls, by listener, method, status code and health authority",
+	}, []string{"listener", "method", "code", "authority"})
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "commons_grpc_request_duration_seconds",
+		Help:    "Seconds taken by GRPC calls, by listener, method and status code",
+		Buckets: latencyBuckets,
+	}, []string{"listener", "method", "code"})
+	grpcMessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_grpc_messages_received",
+		Help: "Number of GRPC messages received from clients, by listener and method",
+	}, []string{"listener", "method"})
+	grpcMessagesSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_grpc_messages_sent",
+		Help: "Number of GRPC messages sent to clients, by listener and method",
+	}, []string{"listener", "method"})
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_http_requests",
+		Help: "Number of finished HTTP requests, by route, HTTP method and status code",
+	}, []string{"route", "method", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "commons_http_request_duration_seconds",
+		Help:    "Seconds taken by HTTP requests, by route and status code",
+		Buckets: latencyBuckets,
+	}, []string{"route", "code"})
+)
+
+// the labels of a call that are only known once inner interceptors have run
+type callLabels struct {
+	sync.Mutex
+	authority string
+}
+
+type callLabelsKey struct{}
+
+// Labels the metrics of the current call with the health authority that made it. Has no
+// effect outside of calls instrumented by Interceptors
+func SetAuthority(ctx context.Context, authority string) {
+	if labels, ok := ctx.Value(callLabelsKey{}).(*callLabels); ok {
+		labels.Lock()
+		labels.authority = authority
+		labels.Unlock()
+	}
+}
+
+// Returns interceptors recording the count, latency, messages and status of every call to
+// a GRPC server. They should come first, so that calls refused by other interceptors are
+// recorded as well
+func Interceptors(listener string) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			labels := &callLabels{}
+			start := time.Now()
+			grpcMessagesReceived.WithLabelValues(listener, info.FullMethod).Inc()
+			resp, err := handler(context.WithValue(ctx, callLabelsKey{}, labels), req)
+			if err == nil {
+				grpcMessagesSent.WithLabelValues(listener, info.FullMethod).Inc()
+			}
+			observeCall(listener, info.FullMethod, labels, start, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			labels := &callLabels{}
+			start := time.Now()
+			err := handler(srv, &countingStream{
+				ServerStream: ss,
+				ctx:          context.WithValue(ss.Context(), callLabelsKey{}, labels),
+				received:     grpcMessagesReceived.WithLabelValues(listener, info.FullMethod),
+				sent:         grpcMessagesSent.WithLabelValues(listener, info.FullMethod),
+			})
+			observeCall(listener, info.FullMethod, labels, start, err)
+			return err
+		}),
+	}
+}
+
+func observeCall(listener, method string, labels *callLabels, start time.Time, err error) {
+	code := status.Code(err).String()
+	labels.Lock()
+	authority := labels.authority
+	labels.Unlock()
+	grpcRequests.WithLabelValues(listener, method, code, authority).Inc()
+	grpcDuration.WithLabelValues(listener, method, code).Observe(time.Since(start).Seconds())
+}
+
+// counts the messages of a stream, and carries the call's labels in its context
+type countingStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	received prometheus.Counter
+	sent     prometheus.Counter
+}
+
+func (s *countingStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *countingStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Inc()
+	}
+	return err
+}
+
+func (s *countingStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.received.Inc()
+	}
+	return err
+}
+
+// Wraps an HTTP handler to record the count, latency and status of its requests under
+// the given route
+func InstrumentHandler(route string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		handler.ServeHTTP(recorder, r)
+		code := strconv.Itoa(recorder.status)
+		httpRequests.WithLabelValues(route, r.Method, code).Inc()
+		httpDuration.WithLabelValues(route, code).Observe(time.Since(start).Seconds())
+	})
+}
+
+// remembers the status code of a response. Flushing is passed on, so that streamed
+// downloads are not buffered
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,29 @@
+package publish
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	publishRuns = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_publish_runs",
+		Help: "Number of publishing runs attempted",
+	})
+	publishFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_publish_failures",
+		Help: "Number of publishing runs that failed for at least one health authority",
+	})
+	publishedBatches = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_published_batches",
+		Help: "Number of export archives written to the blob store",
+	})
+	publishedKeys = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_published_keys",
+		Help: "Number of diagnosis keys written to the blob store",
+	})
+	prunedArchives = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_pruned_archives",
+		Help: "Number of expired export archives deleted from the blob store",
+	})
+)
@@ -0,0 +1,296 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/covista/commons/internal/blobstore"
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/export"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/retention"
+	"github.com/covista/commons/proto"
+)
+
+const (
+	defaultInterval = time.Hour
+	// name of the object listing the archives of each health authority
+	indexName = "index.txt"
+)
+
+// Publisher periodically writes the keys uploaded since its previous run to a blob store,
+// as signed export archives that never change once written. Each health authority's
+// archives are listed, oldest first, in its index.txt:
+//
+//	<prefix><authority_id>/index.txt
+//	<prefix><authority_id>/<start>-<end>-00001.zip
+//
+// where start and end are the Unix times of the previous and the current run. Archives that
+// end before the retention period are removed from the index and then deleted. The cursor of
+// the last published upload is kept in the store rather than next to the public archives, so
+// publishing continues where it left off after a restart. Replicas take turns: a run is
+// skipped while another replica is publishing
+type Publisher struct {
+	exporter    *export.Exporter
+	authorities database.AdminStore
+	state       database.PublishStore
+	blobs       blobstore.Store
+	prefix      string
+	interval    time.Duration
+	retention   database.RetentionPolicy
+	// hex-encoded authority_ids already warned about having no signing keys
+	unsigned map[string]bool
+}
+
+// Result counts what a run published
+type Result struct {
+	Batches int
+	Keys    int
+	// archives deleted (or, for a dry run, that would have been deleted) as expired
+	Pruned int
+	// true if another replica was already publishing and nothing was done
+	Skipped bool
+}
+
+// Creates a new Publisher for the configured blob store. The store must be able to list
+// health authorities and keep the publishing state
+func NewFromConfig(db database.Store, cfg *config.Config) (*Publisher, error) {
+	// the blob store checks the Publish section
+	blobs, err := blobstore.NewFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	authorities, ok := db.(database.AdminStore)
+	if !ok {
+		return nil, errors.New("Publishing requires a store that can list health authorities")
+	}
+	state, ok := db.(database.PublishStore)
+	if !ok {
+		return nil, errors.New("Publishing requires a store that can keep the publishing state")
+	}
+	exporter, err := export.NewFromConfig(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := retention.PolicyFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	interval := cfg.Publish.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	return &Publisher{
+		exporter:    exporter,
+		authorities: authorities,
+		state:       state,
+		blobs:       blobs,
+		prefix:      cfg.Publish.Prefix,
+		interval:    interval,
+		retention:   policy,
+		unsigned:    make(map[string]bool),
+	}, nil
+}
+
+// Publishes the keys uploaded since the previous run for every active health authority, and
+// prunes the expired archives of every authority, disabled ones included. Authorities without
+// signing keys are skipped, since phones reject unsigned archives; their keys are published
+// once a key is configured. A failure for one authority does not keep
+// the others from being published. Does nothing if another replica is already publishing,
+// since the index of each authority is read, extended and written back
+func (p *Publisher) Publish(ctx context.Context) (*Result, error) {
+	result := &Result{}
+	locked, err := p.state.WithPublishLock(ctx, func(ctx context.Context) error {
+		return p.publish(ctx, result)
+	})
+	if err != nil {
+		return result, err
+	} else if !locked {
+		logging.FromContext(ctx).Info("Another replica is publishing; skipping this run")
+		result.Skipped = true
+	}
+	return result, nil
+}
+
+func (p *Publisher) publish(ctx context.Context, result *Result) error {
+	publishRuns.Inc()
+	authorities, err := p.authorities.ListAuthorities(ctx, &proto.ListAuthoritiesRequest{IncludeDisabled: true})
+	if err != nil {
+		publishFailures.Inc()
+		return fmt.Errorf("Could not list health authorities: %w", err)
+	}
+	var failed []string
+	for _, authority := range authorities {
+		var err error
+		if !authority.Disabled {
+			err = p.publishAuthority(ctx, authority, result)
+		}
+		if errors.Is(err, export.ErrNoSigningKeys) {
+			if id := hex.EncodeToString(authority.AuthorityId); !p.unsigned[id] {
+				logging.FromContext(ctx).Warnf("Not publishing keys of authority %s (%s): it has no signing keys", authority.Name, id)
+				p.unsigned[id] = true
+			}
+			err = nil
+		}
+		if err == nil {
+			err = p.pruneAuthority(ctx, authority, result)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Errorf("Could not publish keys of authority %x: %s", authority.AuthorityId, err)
+			failed = append(failed, hex.EncodeToString(authority.AuthorityId))
+		}
+	}
+	if len(failed) > 0 {
+		publishFailures.Inc()
+		return fmt.Errorf("Could not publish keys of authorities %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (p *Publisher) publishAuthority(ctx context.Context, authority *proto.Authority, result *Result) error {
+	log := logging.FromContext(ctx)
+	dir := p.prefix + hex.EncodeToString(authority.AuthorityId)
+	cursor, last, err := p.state.PublishState(ctx, authority.AuthorityId)
+	if err != nil {
+		return err
+	}
+	end := time.Now().UTC().Truncate(time.Second)
+	start := last
+	if start.IsZero() {
+		start = end.Add(-p.interval)
+	}
+
+	batches, next, err := p.exporter.ExportSince(ctx, authority.AuthorityId, cursor, start, end)
+	if err != nil {
+		return err
+	}
+	if len(batches) > 0 {
+		var names []string
+		for _, batch := range batches {
+			var archive bytes.Buffer
+			if err := batch.WriteArchive(&archive); err != nil {
+				return err
+			}
+			name := path.Join(dir, batch.Filename())
+			if err := p.blobs.Put(ctx, name, archive.Bytes(), "application/zip"); err != nil {
+				return err
+			}
+			names = append(names, name)
+			result.Batches++
+			result.Keys += len(batch.Keys)
+			publishedBatches.Inc()
+			publishedKeys.Add(float64(len(batch.Keys)))
+		}
+		// listed only once all of its archives exist, so that clients never fetch a missing one
+		if err := p.appendIndex(ctx, dir, names); err != nil {
+			return err
+		}
+		log.Infof("Published %d batches for authority %s (%x)", len(batches), authority.Name, authority.AuthorityId)
+	}
+	if len(batches) == 0 && next == cursor {
+		// the next batch covers this run's time as well
+		return nil
+	}
+	// saved last: after a failure, the keys are published again rather than not at all
+	return p.state.SavePublishState(ctx, authority.AuthorityId, next, end)
+}
+
+func (p *Publisher) appendIndex(ctx context.Context, dir string, names []string) error {
+	name := path.Join(dir, indexName)
+	index, err := p.blobs.Get(ctx, name)
+	if err != nil && err != blobstore.ErrNotExist {
+		return err
+	}
+	for _, archive := range names {
+		index = append(index, archive+"\n"...)
+	}
+	return p.blobs.Put(ctx, name, index, "text/plain")
+}
+
+// Removes the archives that end before the authority's retention period from its index, then
+// deletes them. Archives are deleted only once unlisted, so that clients never fetch a missing one
+func (p *Publisher) pruneAuthority(ctx context.Context, authority *proto.Authority, result *Result) error {
+	log := logging.FromContext(ctx)
+	id := hex.EncodeToString(authority.AuthorityId)
+	period, found := p.retention.AuthorityPeriods[id]
+	if !found {
+		period = p.retention.Period
+	}
+	cutoff := time.Now().UTC().Add(-period)
+
+	name := path.Join(p.prefix+id, indexName)
+	index, err := p.blobs.Get(ctx, name)
+	if err == blobstore.ErrNotExist {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var kept, expired []string
+	for _, archive := range strings.Fields(string(index)) {
+		end, err := export.FilenameEnd(path.Base(archive))
+		if err != nil {
+			// kept rather than guessed at
+			log.Warnf("Not pruning %s from %s: %s", archive, name, err)
+		} else if end.Before(cutoff) {
+			expired = append(expired, archive)
+			continue
+		}
+		kept = append(kept, archive)
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+	if p.retention.DryRun {
+		log.Infof("Retention dry run: would delete %d archives of authority %s (%s)", len(expired), authority.Name, id)
+		result.Pruned += len(expired)
+		return nil
+	}
+
+	index = nil
+	for _, archive := range kept {
+		index = append(index, archive+"\n"...)
+	}
+	if err := p.blobs.Put(ctx, name, index, "text/plain"); err != nil {
+		return err
+	}
+	deleted := 0
+	for _, archive := range expired {
+		if err := p.blobs.Delete(ctx, archive); err != nil {
+			// unlisted already, so a failed deletion only leaves an unreachable object behind
+			log.Errorf("Could not delete expired archive %s: %s", archive, err)
+			continue
+		}
+		deleted++
+	}
+	result.Pruned += deleted
+	prunedArchives.Add(float64(deleted))
+	log.Infof("Deleted %d expired archives of authority %s (%s)", deleted, authority.Name, id)
+	return nil
+}
+
+// Publishes immediately and then on every interval until the context is cancelled
+func (p *Publisher) Run(ctx context.Context) error {
+	log := logging.FromContext(ctx)
+	log.Infof("Publishing new keys every %s", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := p.Publish(ctx); err != nil {
+			// a failed run is retried on the next interval
+			log.Errorf("Publishing failed: %s", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
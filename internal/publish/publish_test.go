@@ -0,0 +1,231 @@
+package publish
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/proto"
+)
+
+// a store and a configuration publishing to a temporary directory
+type testSetup struct {
+	store *database.MemoryStore
+	cfg   *config.Config
+	dir   string
+}
+
+func newTestSetup(t *testing.T) *testSetup {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "publish")
+	if err != nil {
+		t.Fatalf("could not create directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	cfg := &config.Config{}
+	cfg.Publish.Backend = "filesystem"
+	cfg.Publish.Directory = filepath.Join(dir, "public")
+	store, err := database.NewMemoryStore(cfg)
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+	return &testSetup{store: store, cfg: cfg, dir: dir}
+}
+
+// creates a health authority that has reported one key, optionally with a signing key
+func (ts *testSetup) addAuthority(t *testing.T, name string, signed bool) []byte {
+	t.Helper()
+	ctx := context.Background()
+	authority, api_key, err := ts.store.CreateAuthority(ctx, &proto.CreateAuthorityRequest{Name: name})
+	if err != nil {
+		t.Fatalf("could not create authority: %v", err)
+	}
+	now := time.Now().UTC()
+	key, err := ts.store.CreateAuthorizationKey(ctx, &proto.TokenRequest{
+		ApiKey:              api_key,
+		PermittedRangeStart: now.Add(-24 * time.Hour).Format(time.RFC3339),
+		PermittedRangeEnd:   now.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("could not create authorization key: %v", err)
+	}
+	tek := make([]byte, 16)
+	copy(tek, authority.AuthorityId)
+	err = ts.store.AddReport(ctx, &proto.Report{
+		AuthorizationKey: key.Key,
+		Reports:          []*proto.TimestampedTEK{{TEK: tek, ENIN: uint32(now.Add(-time.Hour).Unix() / 600)}},
+	})
+	if err != nil {
+		t.Fatalf("could not add report: %v", err)
+	}
+	if signed {
+		ts.cfg.Signing.Keys = append(ts.cfg.Signing.Keys, config.SigningKey{
+			AuthorityID:    hex.EncodeToString(authority.AuthorityId),
+			KeyID:          "310",
+			KeyVersion:     "v1",
+			PrivateKeyFile: ts.writeKey(t, name),
+		})
+	}
+	return authority.AuthorityId
+}
+
+// writes a new private key to a file and returns its name
+func (ts *testSetup) writeKey(t *testing.T, name string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not marshal key: %v", err)
+	}
+	filename := filepath.Join(ts.dir, name+".pem")
+	if err := ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("could not write key: %v", err)
+	}
+	return filename
+}
+
+func (ts *testSetup) publisher(t *testing.T) *Publisher {
+	t.Helper()
+	publisher, err := NewFromConfig(ts.store, ts.cfg)
+	if err != nil {
+		t.Fatalf("could not create publisher: %v", err)
+	}
+	return publisher
+}
+
+// returns the archives listed in the authority's index, or nil if it has none
+func (ts *testSetup) index(t *testing.T, authority_id []byte) []string {
+	t.Helper()
+	index, err := ioutil.ReadFile(filepath.Join(ts.cfg.Publish.Directory, hex.EncodeToString(authority_id), indexName))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatalf("could not read index: %v", err)
+	}
+	return strings.Fields(string(index))
+}
+
+func TestPublishSkipsDisabledAndUnsignedAuthorities(t *testing.T) {
+	ctx := context.Background()
+	ts := newTestSetup(t)
+	signed := ts.addAuthority(t, "signed", true)
+	unsigned := ts.addAuthority(t, "unsigned", false)
+	disabled := ts.addAuthority(t, "disabled", true)
+	if _, err := ts.store.DisableAuthority(ctx, &proto.DisableAuthorityRequest{AuthorityId: disabled}); err != nil {
+		t.Fatalf("could not disable authority: %v", err)
+	}
+	publisher := ts.publisher(t)
+
+	for run := 0; run < 2; run++ {
+		result, err := publisher.Publish(ctx)
+		if err != nil {
+			t.Fatalf("run %d failed: %v", run, err)
+		} else if expected := 1 - run; result.Batches != expected {
+			t.Fatalf("run %d published %d batches, expected %d", run, result.Batches, expected)
+		}
+	}
+	if archives := ts.index(t, signed); len(archives) != 1 {
+		t.Fatalf("expected 1 archive for the signed authority, got %v", archives)
+	}
+	if archives := ts.index(t, unsigned); archives != nil {
+		t.Fatalf("expected no archives for the authority without signing keys, got %v", archives)
+	}
+	if archives := ts.index(t, disabled); archives != nil {
+		t.Fatalf("expected no archives for the disabled authority, got %v", archives)
+	}
+}
+
+// writes an archive ending at the given time and lists it in the authority's index
+func (ts *testSetup) addArchive(t *testing.T, authority_id []byte, end time.Time) string {
+	t.Helper()
+	dir := filepath.Join(ts.cfg.Publish.Directory, hex.EncodeToString(authority_id))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("could not create directory: %v", err)
+	}
+	name := path.Join(hex.EncodeToString(authority_id), fmt.Sprintf("%d-%d-00001.zip", end.Add(-time.Hour).Unix(), end.Unix()))
+	if err := ioutil.WriteFile(filepath.Join(ts.cfg.Publish.Directory, name), []byte("archive"), 0644); err != nil {
+		t.Fatalf("could not write archive: %v", err)
+	}
+	index, err := os.OpenFile(filepath.Join(dir, indexName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("could not open index: %v", err)
+	}
+	defer index.Close()
+	if _, err := index.WriteString(name + "\n"); err != nil {
+		t.Fatalf("could not write index: %v", err)
+	}
+	return name
+}
+
+func (ts *testSetup) exists(t *testing.T, name string) bool {
+	t.Helper()
+	_, err := os.Stat(filepath.Join(ts.cfg.Publish.Directory, name))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("could not stat %s: %v", name, err)
+	}
+	return err == nil
+}
+
+func TestPublishPrunesExpiredArchives(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, dryRun := range []bool{false, true} {
+		t.Run(fmt.Sprintf("dry run %t", dryRun), func(t *testing.T) {
+			ts := newTestSetup(t)
+			ts.cfg.Retention.DryRun = dryRun
+			signed := ts.addAuthority(t, "signed", true)
+			disabled := ts.addAuthority(t, "disabled", true)
+			if _, err := ts.store.DisableAuthority(ctx, &proto.DisableAuthorityRequest{AuthorityId: disabled}); err != nil {
+				t.Fatalf("could not disable authority: %v", err)
+			}
+			expired := []string{ts.addArchive(t, signed, now.Add(-15*24*time.Hour)), ts.addArchive(t, disabled, now.Add(-15*24*time.Hour))}
+			kept := []string{ts.addArchive(t, signed, now.Add(-13*24*time.Hour)), ts.addArchive(t, disabled, now.Add(-13*24*time.Hour))}
+
+			result, err := ts.publisher(t).Publish(ctx)
+			if err != nil {
+				t.Fatalf("could not publish: %v", err)
+			} else if result.Pruned != len(expired) {
+				t.Fatalf("pruned %d archives, expected %d", result.Pruned, len(expired))
+			}
+			for _, name := range expired {
+				if ts.exists(t, name) != dryRun {
+					t.Fatalf("expected %s to exist: %t", name, dryRun)
+				}
+			}
+			for _, name := range kept {
+				if !ts.exists(t, name) {
+					t.Fatalf("expected %s to be kept", name)
+				}
+			}
+			expectedSigned, expectedDisabled := []string{kept[0]}, []string{kept[1]}
+			if dryRun {
+				expectedSigned, expectedDisabled = []string{expired[0], kept[0]}, []string{expired[1], kept[1]}
+			}
+			// the signed authority's new archive is listed after the others
+			if archives := ts.index(t, signed); len(archives) != len(expectedSigned)+1 ||
+				strings.Join(archives[:len(expectedSigned)], " ") != strings.Join(expectedSigned, " ") {
+				t.Fatalf("unexpected index for the signed authority: %v", archives)
+			}
+			if archives := ts.index(t, disabled); strings.Join(archives, " ") != strings.Join(expectedDisabled, " ") {
+				t.Fatalf("unexpected index for the disabled authority: %v", archives)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package ratelimit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_rate_limited_requests",
+		Help: "Number of calls rejected by a rate limit, by limit and method",
+	}, []string{"limit", "method"})
+	rateLimitBuckets = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "commons_rate_limit_buckets",
+		Help: "Number of clients or authorities currently tracked by a rate limit",
+	}, []string{"limit"})
+)
@@ -0,0 +1,208 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// how often buckets that have filled up again are forgotten
+const sweepInterval = time.Minute
+
+// response header telling clients when to retry. The HTTP gateway passes it on as Retry-After
+const RetryAfterKey = "retry-after"
+
+// Limiter keeps a token bucket per key, e.g. per client address
+type Limiter struct {
+	// e.g. "client" or "authority"; labels the metrics
+	kind string
+	// what the limit applies to, for error messages; usually the kind
+	subject string
+	rate    float64
+	burst   float64
+
+	sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Creates a new Limiter on the given subject, e.g. "client", enforcing the given limit or the
+// fallback if the limit is not set. Unlike NewLimiter, the result is never nil, so the limit
+// cannot be turned off
+func NewLimiterWithDefault(kind, subject string, limit, fallback config.Limit) (*Limiter, error) {
+	if limit.Rate == 0 {
+		limit = fallback
+	}
+	if limit.Rate <= 0 {
+		return nil, fmt.Errorf("Invalid %s rate limit: rate must be positive", kind)
+	}
+	l, err := NewLimiter(kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	l.subject = subject
+	return l, nil
+}
+
+// Creates a new Limiter enforcing the given limit. Returns nil if the limit is disabled
+func NewLimiter(kind string, limit config.Limit) (*Limiter, error) {
+	if limit.Rate < 0 || limit.Burst < 0 {
+		return nil, fmt.Errorf("Invalid %s rate limit: rate and burst must not be negative", kind)
+	} else if limit.Rate == 0 {
+		return nil, nil
+	}
+	burst := float64(limit.Burst)
+	if burst == 0 {
+		burst = math.Ceil(limit.Rate)
+	}
+	return &Limiter{
+		kind:    kind,
+		subject: kind,
+		rate:    limit.Rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		sweptAt: time.Now(),
+	}, nil
+}
+
+// Takes a token from the key's bucket. If it is empty, returns false along with the time
+// until the next token is available
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+	now := time.Now()
+	l.sweep(now)
+
+	b := l.refill(key, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// Like Allow, but leaves the token in the bucket
+func (l *Limiter) Peek(key string) (bool, time.Duration) {
+	l.Lock()
+	defer l.Unlock()
+	now := time.Now()
+	l.sweep(now)
+
+	b := l.refill(key, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	return true, 0
+}
+
+// returns the key's bucket with the tokens it earned since it was last used. Must be called
+// with the lock held
+func (l *Limiter) refill(key string, now time.Time) *bucket {
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+		rateLimitBuckets.WithLabelValues(l.kind).Set(float64(len(l.buckets)))
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+		b.updated = now
+	}
+	return b
+}
+
+// must be called with the lock held
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < sweepInterval {
+		return
+	}
+	l.sweptAt = now
+	// a bucket is full again once it has been idle for long enough to refill completely
+	idle := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= idle {
+			delete(l.buckets, key)
+		}
+	}
+	rateLimitBuckets.WithLabelValues(l.kind).Set(float64(len(l.buckets)))
+}
+
+// Returns an interceptor applying the limiter to the given unary methods. Calls are limited
+// per key(ctx); calls for which it returns false are let through. A nil Limiter returns no
+// interceptors
+func (l *Limiter) Interceptors(methods map[string]bool, key func(ctx context.Context) (string, bool)) []grpc.ServerOption {
+	if l == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if !methods[info.FullMethod] {
+				return handler(ctx, req)
+			}
+			if k, ok := key(ctx); ok {
+				if allowed, wait := l.Allow(k); !allowed {
+					rateLimited.WithLabelValues(l.kind, info.FullMethod).Inc()
+					return nil, l.exhausted(ctx, wait)
+				}
+			}
+			return handler(ctx, req)
+		}),
+	}
+}
+
+// Returns an interceptor that only charges the limiter for calls to the given unary methods
+// that fail with an error for which failed returns true, e.g. wrong guesses of a secret. Once
+// a key has run out of failures, its calls are refused without reaching the handler. A nil
+// Limiter returns no interceptors
+func (l *Limiter) FailureInterceptors(methods map[string]bool, key func(ctx context.Context) (string, bool), failed func(err error) bool) []grpc.ServerOption {
+	if l == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if !methods[info.FullMethod] {
+				return handler(ctx, req)
+			}
+			k, ok := key(ctx)
+			if !ok {
+				return handler(ctx, req)
+			}
+			if allowed, wait := l.Peek(k); !allowed {
+				rateLimited.WithLabelValues(l.kind, info.FullMethod).Inc()
+				return nil, l.exhausted(ctx, wait)
+			}
+			resp, err := handler(ctx, req)
+			if err != nil && failed(err) {
+				l.Allow(k)
+			}
+			return resp, err
+		}),
+	}
+}
+
+// builds the ResourceExhausted error, with a RetryInfo detail and a retry-after header
+func (l *Limiter) exhausted(ctx context.Context, wait time.Duration) error {
+	seconds := int(math.Ceil(wait.Seconds()))
+	// the header is best effort; the status carries the same hint
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RetryAfterKey, strconv.Itoa(seconds)))
+	st := status.Newf(codes.ResourceExhausted, "Too many requests from this %s; retry in %ds", l.subject, seconds)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
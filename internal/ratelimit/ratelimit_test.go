@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/covista/commons/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const healthCheck = "/grpc.health.v1.Health/Check"
+
+// the health service answers NotFound for unknown services, which stands in for failed calls
+func newHealthClient(t *testing.T, options ...grpc.ServerOption) grpc_health_v1.HealthClient {
+	t.Helper()
+	server := grpc.NewServer(options...)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("known", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	listener := bufconn.Listen(1 << 16)
+	go server.Serve(listener)
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("could not dial server: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func everyone(context.Context) (string, bool) {
+	return "everyone", true
+}
+
+func TestNewLimiterWithDefault(t *testing.T) {
+	fallback := config.Limit{Rate: 2, Burst: 5}
+	for _, tt := range []struct {
+		name  string
+		limit config.Limit
+		rate  float64
+		burst float64
+		err   bool
+	}{
+		{"unset", config.Limit{}, 2, 5, false},
+		{"set", config.Limit{Rate: 1, Burst: 3}, 1, 3, false},
+		{"default burst", config.Limit{Rate: 1.5}, 1.5, 2, false},
+		{"negative", config.Limit{Rate: -1}, 0, 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewLimiterWithDefault("test", "client", tt.limit, fallback)
+			if tt.err {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if l == nil || l.rate != tt.rate || l.burst != tt.burst {
+				t.Fatalf("expected rate %v and burst %v, got %+v", tt.rate, tt.burst, l)
+			}
+		})
+	}
+}
+
+func TestInterceptors(t *testing.T) {
+	l, err := NewLimiter("test", config.Limit{Rate: 1e-6, Burst: 2})
+	if err != nil {
+		t.Fatalf("could not create limiter: %v", err)
+	}
+	client := newHealthClient(t, l.Interceptors(map[string]bool{healthCheck: true}, everyone)...)
+	check := func(service string, code codes.Code) {
+		t.Helper()
+		if _, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service}); status.Code(err) != code {
+			t.Fatalf("expected %s for %q, got %v", code, service, err)
+		}
+	}
+	// failed calls count as well
+	check("known", codes.OK)
+	check("unknown", codes.NotFound)
+	check("known", codes.ResourceExhausted)
+	check("unknown", codes.ResourceExhausted)
+}
+
+func TestFailureInterceptors(t *testing.T) {
+	notFound := func(err error) bool {
+		return status.Code(err) == codes.NotFound
+	}
+	for _, tt := range []struct {
+		name string
+		// services checked in turn; "known" succeeds, anything else fails
+		services []string
+		codes    []codes.Code
+	}{
+		{"successes are free", []string{"known", "known", "known", "known"}, []codes.Code{codes.OK, codes.OK, codes.OK, codes.OK}},
+		{"failures within burst", []string{"a", "known", "known"}, []codes.Code{codes.NotFound, codes.OK, codes.OK}},
+		{"failures exhausted", []string{"a", "b", "known", "c"}, []codes.Code{codes.NotFound, codes.NotFound, codes.ResourceExhausted, codes.ResourceExhausted}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewLimiterWithDefault("test_failures", "client", config.Limit{}, config.Limit{Rate: 1e-6, Burst: 2})
+			if err != nil {
+				t.Fatalf("could not create limiter: %v", err)
+			}
+			client := newHealthClient(t, l.FailureInterceptors(map[string]bool{healthCheck: true}, everyone, notFound)...)
+			for idx, service := range tt.services {
+				_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+				if status.Code(err) != tt.codes[idx] {
+					t.Fatalf("call %d: expected %s, got %v", idx, tt.codes[idx], err)
+				}
+			}
+		})
+	}
+}
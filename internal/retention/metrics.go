@@ -0,0 +1,21 @@
+package retention
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	retentionRuns = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_retention_runs",
+		Help: "Number of retention purges attempted",
+	})
+	retentionFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "commons_retention_failures",
+		Help: "Number of retention purges that failed",
+	})
+	retentionDeletedRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "commons_retention_deleted_rows",
+		Help: "Rows deleted by the retention purge (or that would have been, for dry runs)",
+	}, []string{"table", "dry_run"})
+)
@@ -0,0 +1,111 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/logging"
+)
+
+const (
+	// keys older than the exposure window are of no use to anyone
+	defaultPeriod   = 14 * 24 * time.Hour
+	defaultInterval = time.Hour
+)
+
+func checkConfig(cfg *config.Config) error {
+	return config.Check(cfg, config.RetentionSection)
+}
+
+// Job periodically deletes diagnosis keys and authorization keys that are past the
+// retention period
+type Job struct {
+	db       database.RetentionStore
+	policy   database.RetentionPolicy
+	interval time.Duration
+}
+
+// Returns the retention policy of the given configuration, which also applies to published
+// archives
+func PolicyFromConfig(cfg *config.Config) (database.RetentionPolicy, error) {
+	if err := checkConfig(cfg); err != nil {
+		return database.RetentionPolicy{}, fmt.Errorf("Invalid config for retention: %w", err)
+	}
+	period := cfg.Retention.Period
+	if period == 0 {
+		period = defaultPeriod
+	}
+	return database.RetentionPolicy{
+		Period:           period,
+		AuthorityPeriods: cfg.Retention.AuthorityPeriods,
+		DryRun:           cfg.Retention.DryRun,
+	}, nil
+}
+
+// Creates a new retention Job from the given configuration
+func NewFromConfig(db database.RetentionStore, cfg *config.Config) (*Job, error) {
+	policy, err := PolicyFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	interval := cfg.Retention.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+	return &Job{
+		db:       db,
+		policy:   policy,
+		interval: interval,
+	}, nil
+}
+
+// Runs one purge
+func (job *Job) Purge(ctx context.Context) (*database.PurgeResult, error) {
+	log := logging.FromContext(ctx)
+	retentionRuns.Inc()
+	result, err := job.db.PurgeExpired(ctx, job.policy)
+	if err != nil {
+		retentionFailures.Inc()
+		return nil, err
+	}
+	if result.Skipped {
+		log.Debug("Retention purge is already running on another replica; skipping")
+		return result, nil
+	}
+
+	dryRun := fmt.Sprintf("%t", job.policy.DryRun)
+	retentionDeletedRows.WithLabelValues("reported_keys", dryRun).Add(float64(result.ReportedKeys))
+	retentionDeletedRows.WithLabelValues("authorization_keys", dryRun).Add(float64(result.AuthorizationKeys))
+	retentionDeletedRows.WithLabelValues("verification_codes", dryRun).Add(float64(result.VerificationCodes))
+	if job.policy.DryRun {
+		log.Infof("Retention dry run: would delete %d reported keys, %d authorization keys and %d verification codes",
+			result.ReportedKeys, result.AuthorizationKeys, result.VerificationCodes)
+	} else {
+		log.Infof("Retention purge deleted %d reported keys, %d authorization keys and %d verification codes",
+			result.ReportedKeys, result.AuthorizationKeys, result.VerificationCodes)
+	}
+	return result, nil
+}
+
+// Purges immediately and then on every interval until the context is cancelled
+func (job *Job) Run(ctx context.Context) error {
+	log := logging.FromContext(ctx)
+	log.Infof("Purging data older than %s every %s (dry run: %t)", job.policy.Period, job.interval, job.policy.DryRun)
+
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := job.Purge(ctx); err != nil {
+			// a failed purge is retried on the next interval
+			log.Errorf("Retention purge failed: %s", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"context"
+
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/proto"
+)
+
+func (srv *Server) CreateAuthority(ctx context.Context, req *proto.CreateAuthorityRequest) (*proto.CreateAuthorityResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	authority, api_key, err := srv.admin.CreateAuthority(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.CreateAuthorityResponse{Error: err.Error()})
+	}
+	return &proto.CreateAuthorityResponse{
+		Authority: authority,
+		ApiKey:    api_key,
+	}, nil
+}
+
+func (srv *Server) ListAuthorities(ctx context.Context, req *proto.ListAuthoritiesRequest) (*proto.ListAuthoritiesResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	authorities, err := srv.admin.ListAuthorities(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.ListAuthoritiesResponse{Error: err.Error()})
+	}
+	return &proto.ListAuthoritiesResponse{
+		Authorities: authorities,
+	}, nil
+}
+
+func (srv *Server) RenameAuthority(ctx context.Context, req *proto.RenameAuthorityRequest) (*proto.AuthorityResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	authority, err := srv.admin.RenameAuthority(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.AuthorityResponse{Error: err.Error()})
+	}
+	return &proto.AuthorityResponse{
+		Authority: authority,
+	}, nil
+}
+
+func (srv *Server) DisableAuthority(ctx context.Context, req *proto.DisableAuthorityRequest) (*proto.AuthorityResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	authority, err := srv.admin.DisableAuthority(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.AuthorityResponse{Error: err.Error()})
+	}
+	return &proto.AuthorityResponse{
+		Authority: authority,
+	}, nil
+}
+
+func (srv *Server) DeleteAuthority(ctx context.Context, req *proto.DeleteAuthorityRequest) (*proto.DeleteAuthorityResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	if err := srv.admin.DeleteAuthority(ctx, req); err != nil {
+		return nil, statusError(ctx, err, &proto.DeleteAuthorityResponse{Error: err.Error()})
+	}
+	return &proto.DeleteAuthorityResponse{}, nil
+}
+
+func (srv *Server) IssueApiKey(ctx context.Context, req *proto.IssueApiKeyRequest) (*proto.IssueApiKeyResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	api_key, err := srv.admin.IssueApiKey(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.IssueApiKeyResponse{Error: err.Error()})
+	}
+	return &proto.IssueApiKeyResponse{
+		ApiKey: api_key,
+	}, nil
+}
+
+func (srv *Server) RevokeApiKey(ctx context.Context, req *proto.RevokeApiKeyRequest) (*proto.RevokeApiKeyResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	if err := srv.admin.RevokeApiKey(ctx, req); err != nil {
+		return nil, statusError(ctx, err, &proto.RevokeApiKeyResponse{Error: err.Error()})
+	}
+	return &proto.RevokeApiKeyResponse{}, nil
+}
+
+func (srv *Server) SetLogLevel(ctx context.Context, req *proto.LogLevelRequest) (*proto.LogLevelResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	if len(req.GetLevel()) > 0 {
+		previous := logging.Level()
+		if err := logging.SetLevel(req.GetLevel()); err != nil {
+			invalid := &database.InvalidFieldError{Field: "level", Description: err.Error()}
+			return nil, statusError(ctx, invalid, &proto.LogLevelResponse{Error: invalid.Error()})
+		}
+		logging.FromContext(ctx).Warnf("Log level changed from %s to %s", previous, logging.Level())
+	}
+	return &proto.LogLevelResponse{
+		Level: logging.Level(),
+	}, nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/logging"
+	pb "github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// converts an error from the store to a GRPC status error. The message is kept as
+// err.Error(), which is what clients used to read from the error field of responses. The
+// legacy response, with its error field set by the caller, is attached as a status detail
+// so that old clients can still find it; the HTTP gateway returns the message in the error
+// field of its JSON error body as well
+func statusError(ctx context.Context, err error, legacy pb.Message) error {
+	var (
+		st      *status.Status
+		details []pb.Message
+		invalid *database.InvalidFieldError
+	)
+	if errors.As(err, &invalid) {
+		st = status.New(codes.InvalidArgument, err.Error())
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       invalid.Field,
+				Description: invalid.Description,
+			}},
+		})
+	} else {
+		code := errorCode(err)
+		if code == codes.Internal || code == codes.Unavailable {
+			logging.FromContext(ctx).Errorf("Request failed: %s", err)
+		}
+		st = status.New(code, err.Error())
+	}
+	if legacy != nil {
+		details = append(details, legacy)
+	}
+	if len(details) > 0 {
+		if detailed, derr := st.WithDetails(details...); derr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
+
+func errorCode(err error) codes.Code {
+	switch {
+	case errors.Is(err, database.ErrUnknownAuthorizationKey),
+		errors.Is(err, database.ErrInvalidVerificationCode),
+		errors.Is(err, database.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, database.ErrInvalidApiKey),
+		errors.Is(err, database.ErrWrongAuthority),
+		errors.Is(err, database.ErrInvalidClientAuthority):
+		return codes.PermissionDenied
+	case errors.Is(err, database.ErrAuthorizationKeyRedeemed),
+		errors.Is(err, database.ErrAuthorizationKeyExpired),
+		errors.Is(err, database.ErrInUse):
+		return codes.FailedPrecondition
+	case errors.Is(err, database.ErrAlreadyExists):
+		return codes.AlreadyExists
+	case errors.Is(err, database.ErrUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
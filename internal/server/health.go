@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/internal/logging"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+	// long enough for load balancers checking every few seconds to notice
+	defaultDrainDelay = 5 * time.Second
+)
+
+// the services reported by the GRPC health service; "" is the server as a whole
+var healthServices = []string{"", "proto.DiagnosisDB"}
+
+var errDraining = errors.New("server is shutting down")
+
+// the result of the latest health check
+type readiness struct {
+	sync.Mutex
+	// nil once the server is ready
+	err error
+}
+
+func (r *readiness) set(err error) {
+	r.Lock()
+	defer r.Unlock()
+	// once draining, the server never becomes ready again
+	if r.err != errDraining {
+		r.err = err
+	}
+}
+
+func (r *readiness) get() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.err
+}
+
+// Checks the store periodically until the server is shut down, and reports the result
+// through the GRPC health service and /readyz
+func (srv *Server) RunHealthChecks() error {
+	log := logging.FromContext(srv.ctx)
+	checker, ok := srv.db.(database.HealthStore)
+	if !ok {
+		srv.setReady(nil)
+		return nil
+	} else if !srv.startBackground() {
+		return nil
+	}
+	defer srv.background.Done()
+
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	var previous error
+	for {
+		ctx, cancel := context.WithTimeout(srv.ctx, healthCheckTimeout)
+		err := checker.CheckHealth(ctx)
+		cancel()
+		if srv.ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && (previous == nil || err.Error() != previous.Error()) {
+			log.Warnf("Not ready: %s", err)
+		} else if err == nil && previous != nil {
+			log.Info("Ready")
+		}
+		previous = err
+		srv.setReady(err)
+
+		select {
+		case <-ticker.C:
+		case <-srv.ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (srv *Server) setReady(err error) {
+	srv.readiness.set(err)
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if err != nil {
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	for _, service := range healthServices {
+		srv.health.SetServingStatus(service, status)
+	}
+}
+
+// reports NOT_SERVING from now on, so that clients move away while calls drain, and waits
+// for the drain delay or until the context expires
+func (srv *Server) drainHealth(ctx context.Context) {
+	srv.readiness.set(errDraining)
+	srv.health.Shutdown()
+	timer := time.NewTimer(srv.drainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// liveness: the process is up and serving HTTP
+func (srv *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readiness: the store is reachable and its schema is current, and the server is not
+// shutting down
+func (srv *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	if err := srv.readiness.get(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/covista/commons/internal/auth"
+	"github.com/covista/commons/internal/certs"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/metrics"
+	"github.com/covista/commons/internal/ratelimit"
+	"github.com/covista/commons/internal/tracing"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// metadata the HTTP gateway uses to pass on the health authority of a client certificate
+// and the client's address. The gateway drops the corresponding headers from incoming
+// requests so they cannot be forged
+const (
+	gatewayAuthorityKey = "commons-client-authority"
+	gatewayAddressKey   = "commons-client-address"
+)
+
+// returns interceptors that attach the health authority found by identify to the context
+func identityInterceptors(identify func(ctx context.Context) context.Context) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(identify(ctx), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, auth.WrapStream(ss, identify(ss.Context())))
+		}),
+	}
+}
+
+// returns interceptors that label the call's metrics with the health authority the client
+// authenticated as, or else presented a certificate for. With withLogger, the authority is
+// added to the call's logger as well
+func authorityLabelInterceptors(withLogger bool) []grpc.ServerOption {
+	label := func(ctx context.Context) context.Context {
+		authority, ok := principalAuthority(ctx)
+		if !ok {
+			authority_id, found := certs.ClientAuthority(ctx)
+			if !found {
+				return ctx
+			}
+			authority = hex.EncodeToString(authority_id)
+		}
+		metrics.SetAuthority(ctx, authority)
+		if withLogger {
+			return logging.WithFields(ctx, "authority", authority)
+		}
+		return ctx
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(label(ctx), req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, auth.WrapStream(ss, label(ss.Context())))
+		}),
+	}
+}
+
+// identifies clients of the public GRPC listener by their verified TLS client certificate
+func identifyPeer(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	if authority_id, ok := certs.AuthorityFromChains(info.State.VerifiedChains); ok {
+		return certs.WithClientAuthority(ctx, authority_id)
+	}
+	return ctx
+}
+
+// identifies clients of the HTTP gateway by the authority it passes on
+func identifyGatewayClient(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(gatewayAuthorityKey); len(values) == 1 {
+		if authority_id, err := hex.DecodeString(values[0]); err == nil {
+			return certs.WithClientAuthority(ctx, authority_id)
+		}
+	}
+	return ctx
+}
+
+// passes the HTTP client's address and the health authority of its certificate on to GRPC
+func gatewayMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.Pairs(gatewayAddressKey, hostOf(r.RemoteAddr))
+	if r.TLS == nil {
+		return md
+	}
+	if authority_id, ok := certs.AuthorityFromChains(r.TLS.VerifiedChains); ok {
+		md.Set(gatewayAuthorityKey, hex.EncodeToString(authority_id))
+	}
+	return md
+}
+
+// forwards headers like the default matcher, except for the ones carrying client identity,
+// and passes X-Request-Id and traceparent on
+func gatewayHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, runtime.MetadataHeaderPrefix+gatewayAuthorityKey) ||
+		strings.EqualFold(key, runtime.MetadataHeaderPrefix+gatewayAddressKey) {
+		return "", false
+	} else if strings.EqualFold(key, requestIDKey) {
+		return requestIDKey, true
+	} else if strings.EqualFold(key, tracing.TraceparentKey) {
+		return tracing.TraceparentKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// passes the retry hint of rate limited calls on as the standard Retry-After header, and
+// the request ID as X-Request-Id
+func gatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	switch key {
+	case ratelimit.RetryAfterKey:
+		return "Retry-After", true
+	case requestIDKey:
+		return "X-Request-Id", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// the address of clients of the public GRPC listener, for rate limiting
+func peerAddress(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+	return hostOf(p.Addr.String()), true
+}
+
+// the address of clients of the HTTP gateway, as passed on by the gateway
+func gatewayClientAddress(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(gatewayAddressKey); len(values) == 1 && len(values[0]) > 0 {
+		return values[0], true
+	}
+	return "", false
+}
+
+// the health authority the caller authenticated as, for rate limiting
+func principalAuthority(ctx context.Context) (string, bool) {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return hex.EncodeToString(principal.AuthorityID), true
+	}
+	return "", false
+}
+
+// strips the port, so that all connections from a host share a limit
+func hostOf(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
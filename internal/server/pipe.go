@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+var errPipeListenerClosed = errors.New("Listener is closed")
+
+// pipeListener is an in-memory net.Listener, which connects the HTTP gateway to its GRPC
+// server without a socket. Each connection is a net.Pipe
+type pipeListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+// Waits for the next connection made with Dial
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errPipeListenerClosed
+	}
+}
+
+// Stops accepting connections; connections already made stay open
+func (l *pipeListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr{}
+}
+
+// Connects to the listener, waiting until the connection is accepted
+func (l *pipeListener) Dial(ctx context.Context) (net.Conn, error) {
+	server, client := net.Pipe()
+	var err error
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		err = errPipeListenerClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	server.Close()
+	client.Close()
+	return nil, err
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "gateway" }
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"github.com/covista/commons/internal/auth"
+	"github.com/covista/commons/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// the principal each RPC of the public listeners must be called by. RPCs missing from this
+// list are refused, so new ones have to be declared here
+var publicPolicy = auth.Policy{
+	"/proto.DiagnosisDB/AddReport":                auth.Public,
+	"/proto.DiagnosisDB/GetDiagnosisKeys":         auth.Public,
+	"/proto.DiagnosisDB/ExchangeVerificationCode": auth.Public,
+	"/proto.DiagnosisDB/GetAuthorizationToken":    auth.HealthAuthority,
+	"/grpc.health.v1.Health/Check":                auth.Public,
+	"/grpc.health.v1.Health/Watch":                auth.Public,
+}
+
+// the RPCs that write to the database, which are subject to the configured rate limits
+var rateLimitedMethods = map[string]bool{
+	"/proto.DiagnosisDB/AddReport":                true,
+	"/proto.DiagnosisDB/GetAuthorizationToken":    true,
+	"/proto.DiagnosisDB/ExchangeVerificationCode": true,
+}
+
+// verification codes are short enough to guess, so exchanging them is always limited per
+// client address, even if the other rate limits are off
+var verificationMethods = map[string]bool{
+	"/proto.DiagnosisDB/ExchangeVerificationCode": true,
+}
+
+var (
+	defaultVerificationLimit        = config.Limit{Rate: 0.2, Burst: 10}
+	defaultVerificationFailureLimit = config.Limit{Rate: 1.0 / 600, Burst: 5}
+)
+
+// tells wrong verification codes apart from malformed requests and database failures
+func wrongVerificationCode(err error) bool {
+	return status.Code(err) == codes.NotFound
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/covista/commons/internal/auth"
+	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/tracing"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// metadata carrying the request ID, both from the client and back in the response headers.
+// The HTTP gateway maps it to and from the X-Request-Id header
+const requestIDKey = "x-request-id"
+
+// longer request IDs from clients are replaced, so that they cannot bloat the logs
+const maxRequestIDLength = 128
+
+// returns the client's request ID if it sent a usable one, or else a new one
+func requestID(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestIDKey); len(values) == 1 && validRequestID(values[0]) {
+		return values[0]
+	}
+	return uuid.New().String()
+}
+
+func validRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// returns interceptors that give every call a logger carrying its request ID, method,
+// client address and trace ID, return the request ID in the response headers and log finished calls at
+// debug level
+func requestLogInterceptors(address func(ctx context.Context) (string, bool)) []grpc.ServerOption {
+	enrich := func(ctx context.Context, method string) context.Context {
+		id := requestID(ctx)
+		// a failure only means the client does not learn the ID
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+		fields := []interface{}{"request_id", id, "method", method}
+		if peer, ok := address(ctx); ok {
+			fields = append(fields, "peer", peer)
+		}
+		if span, ok := tracing.SpanContextFromContext(ctx); ok {
+			fields = append(fields, "trace_id", span.TraceID.String())
+		}
+		return logging.WithFields(logging.WithLogger(ctx), fields...)
+	}
+	finished := func(ctx context.Context, start time.Time, err error) {
+		logging.FromContext(ctx).Debugw("Finished call", "code", status.Code(err).String(), "duration", time.Since(start))
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			start := time.Now()
+			ctx = enrich(ctx, info.FullMethod)
+			resp, err := handler(ctx, req)
+			finished(ctx, start, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			start := time.Now()
+			ctx := enrich(ss.Context(), info.FullMethod)
+			err := handler(srv, auth.WrapStream(ss, ctx))
+			finished(ctx, start, err)
+			return err
+		}),
+	}
+}
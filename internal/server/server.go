@@ -6,34 +6,66 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/covista/commons/internal/auth"
+	"github.com/covista/commons/internal/certs"
 	"github.com/covista/commons/internal/config"
 	"github.com/covista/commons/internal/database"
 	"github.com/covista/commons/internal/logging"
+	"github.com/covista/commons/internal/metrics"
+	"github.com/covista/commons/internal/publish"
+	"github.com/covista/commons/internal/ratelimit"
+	"github.com/covista/commons/internal/retention"
+	"github.com/covista/commons/internal/tracing"
 	"github.com/covista/commons/proto"
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func checkConfig(cfg *config.Config) error {
-	if cfg == nil {
-		return errors.New("Configuration is nil")
-	} else if len(cfg.GRPC.Port) == 0 {
-		return errors.New("GRPC.Port is empty")
-	} else if len(cfg.GRPC.ListenAddress) == 0 {
-		return errors.New("GRPC.ListenAddress is empty")
-	} else {
-		return nil
-	}
+	return config.Check(cfg, config.ServerSection)
 }
 
 type Server struct {
-	ctx         context.Context
-	db          *database.Database
-	grpcAddress string
-	httpAddress string
-	grpcServer  *grpc.Server
+	// cancelled on shutdown to stop background work
+	ctx    context.Context
+	cancel context.CancelFunc
+	db     database.Store
+	// db behind the key cache, if enabled; serves downloads and reports so that reports
+	// invalidate cached downloads
+	keys database.Store
+	// nil if the store cannot manage health authorities
+	admin        database.AdminStore
+	grpcAddress  string
+	httpAddress  string
+	adminAddress string
+	grpcServer   *grpc.Server
+	adminServer  *grpc.Server
+	retention    *retention.Job
+	// nil if publishing is not configured
+	publisher *publish.Publisher
+	// nil if TLS is not configured
+	tls *certs.Reloader
+	// serves the HTTP gateway in-process, so that it can pass on client identities
+	gatewayServer   *grpc.Server
+	gatewayListener *pipeListener
+	gatewayConn     *grpc.ClientConn
+	httpServer      *http.Server
+	// serve /metrics on the HTTP listener
+	metricsOnHTTP bool
+	// tracks background work that uses the store, like the retention job. The lock keeps
+	// work from starting once shutdown has begun
+	background     sync.WaitGroup
+	backgroundLock sync.Mutex
+	health         *health.Server
+	readiness      readiness
+	// how long health checks report not serving before the listeners stop
+	drainDelay time.Duration
 }
 
 func NewWithInsecureDefaults(ctx context.Context) (*Server, error) {
@@ -47,6 +79,11 @@ func NewWithInsecureDefaults(ctx context.Context) (*Server, error) {
 			ListenAddress: "localhost",
 			Port:          "5001",
 		},
+		Admin: config.Admin{
+			ListenAddress: "localhost",
+			Port:          "5002",
+			Token:         "insecureadmintoken",
+		},
 		Database: config.Database{
 			Host:     "localhost",
 			Database: "covid19",
@@ -59,37 +96,249 @@ func NewWithInsecureDefaults(ctx context.Context) (*Server, error) {
 	return NewFromConfig(ctx, cfg)
 }
 
+// Creates a new Server with the store selected by Database.Backend
 func NewFromConfig(ctx context.Context, cfg *config.Config) (*Server, error) {
+	var store database.Store
+	switch cfg.Database.Backend {
+	case "", "postgres":
+		db, err := database.NewFromConfig(ctx, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("Could not connect to database: %w", err)
+		}
+		if err := prepareSchema(ctx, cfg, db); err != nil {
+			db.Close()
+			return nil, err
+		}
+		store = db
+	case "memory":
+		mem, err := database.NewMemoryStore(cfg)
+		if err != nil {
+			return nil, err
+		}
+		store = mem
+	default:
+		return nil, fmt.Errorf("Unknown database backend %q", cfg.Database.Backend)
+	}
+	return New(ctx, cfg, store)
+}
+
+// migrates the database if configured to, and otherwise makes sure it has already been
+// migrated to the schema this server expects
+func prepareSchema(ctx context.Context, cfg *config.Config, db *database.Database) error {
+	log := logging.FromContext(ctx)
+	if cfg.Database.Migrate {
+		applied, err := db.MigrateUp(ctx)
+		if err != nil {
+			return fmt.Errorf("Could not migrate database: %w", err)
+		}
+		log.Infof("Applied %d migrations", len(applied))
+	}
+	if err := db.CheckSchema(ctx); err != nil {
+		return fmt.Errorf("Refusing to start: %w", err)
+	}
+	return nil
+}
+
+// Creates a new Server backed by the given store. The Admin service and the retention
+// job are only available if the store implements AdminStore and RetentionStore
+func New(ctx context.Context, cfg *config.Config, store database.Store) (*Server, error) {
+	if err := checkConfig(cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config for server: %w", err)
+	}
 	grpcAddress := fmt.Sprintf("%s:%s", cfg.GRPC.ListenAddress, cfg.GRPC.Port)
 	httpAddress := fmt.Sprintf("%s:%s", cfg.HTTP.ListenAddress, cfg.HTTP.Port)
+	var adminAddress string
+	if len(cfg.Admin.Port) > 0 {
+		// the Admin service is for operators, so it is not reachable from outside unless asked for
+		adminListenAddress := cfg.Admin.ListenAddress
+		if len(adminListenAddress) == 0 {
+			adminListenAddress = "127.0.0.1"
+		}
+		adminAddress = net.JoinHostPort(adminListenAddress, cfg.Admin.Port)
+	}
 
-	db, err := database.NewFromConfig(ctx, cfg)
+	reloader, err := certs.NewReloaderFromConfig(ctx, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("Could not connect to database: %w", err)
+		return nil, err
+	}
+	clientLimiter, err := ratelimit.NewLimiter("client", cfg.RateLimit.Client)
+	if err != nil {
+		return nil, err
+	}
+	authorityLimiter, err := ratelimit.NewLimiter("authority", cfg.RateLimit.Authority)
+	if err != nil {
+		return nil, err
+	}
+	verificationLimiter, err := ratelimit.NewLimiterWithDefault("verification", "client", cfg.RateLimit.Verification, defaultVerificationLimit)
+	if err != nil {
+		return nil, err
+	}
+	verificationFailures, err := ratelimit.NewLimiterWithDefault("verification_failures", "client", cfg.RateLimit.VerificationFailures, defaultVerificationFailureLimit)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := database.NewKeyCacheFromConfig(cfg, store)
+	if err != nil {
+		return nil, err
+	}
+	authenticator := auth.NewAuthenticator(store, publicPolicy)
+	// clients are limited before authenticating, so that floods do not reach the store
+	interceptors := func(listener string, identify func(context.Context) context.Context, address func(context.Context) (string, bool)) []grpc.ServerOption {
+		var options []grpc.ServerOption
+		options = append(options, metrics.Interceptors(listener)...)
+		options = append(options, tracing.Interceptors()...)
+		options = append(options, requestLogInterceptors(address)...)
+		options = append(options, identityInterceptors(identify)...)
+		options = append(options, authorityLabelInterceptors(false)...)
+		options = append(options, clientLimiter.Interceptors(rateLimitedMethods, address)...)
+		options = append(options, verificationLimiter.Interceptors(verificationMethods, address)...)
+		options = append(options, verificationFailures.FailureInterceptors(verificationMethods, address, wrongVerificationCode)...)
+		options = append(options, authenticator.Interceptors()...)
+		options = append(options, authorityLabelInterceptors(true)...)
+		options = append(options, authorityLimiter.Interceptors(rateLimitedMethods, principalAuthority)...)
+		return options
+	}
+	grpcOptions := interceptors("grpc", identifyPeer, peerAddress)
+	var adminOptions []grpc.ServerOption
+	adminOptions = append(adminOptions, metrics.Interceptors("admin")...)
+	adminOptions = append(adminOptions, tracing.Interceptors()...)
+	adminOptions = append(adminOptions, requestLogInterceptors(peerAddress)...)
+	adminOptions = append(adminOptions, auth.TokenInterceptors(cfg.Admin.Token)...)
+	if reloader != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
 	srv := &Server{
-		ctx:         ctx,
-		grpcAddress: grpcAddress,
-		httpAddress: httpAddress,
-		db:          db,
-		grpcServer:  grpc.NewServer(),
+		ctx:             ctx,
+		cancel:          cancel,
+		grpcAddress:     grpcAddress,
+		httpAddress:     httpAddress,
+		adminAddress:    adminAddress,
+		metricsOnHTTP:   cfg.Metrics.OnHTTP,
+		db:              store,
+		keys:            keys,
+		grpcServer:      grpc.NewServer(grpcOptions...),
+		adminServer:     grpc.NewServer(adminOptions...),
+		tls:             reloader,
+		gatewayServer:   grpc.NewServer(interceptors("gateway", identifyGatewayClient, gatewayClientAddress)...),
+		gatewayListener: newPipeListener(),
+		drainDelay:      cfg.Shutdown.DrainDelay,
+	}
+	if srv.drainDelay == 0 {
+		srv.drainDelay = defaultDrainDelay
+	}
+	srv.readiness.err = errors.New("Health has not been checked yet")
+	srv.health = health.NewServer()
+	for _, service := range healthServices {
+		srv.health.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
 	}
+	grpc_health_v1.RegisterHealthServer(srv.grpcServer, srv.health)
 	proto.RegisterDiagnosisDBServer(srv.grpcServer, srv)
+	proto.RegisterDiagnosisDBServer(srv.gatewayServer, srv)
+	if admin, ok := store.(database.AdminStore); ok {
+		srv.admin = admin
+		proto.RegisterAdminServer(srv.adminServer, srv)
+	}
+	if _, ok := store.(database.RetentionStore); ok {
+		// purges through the key cache, if enabled, so that it drops purged keys
+		retentionJob, err := retention.NewFromConfig(keys.(database.RetentionStore), cfg)
+		if err != nil {
+			return nil, err
+		}
+		srv.retention = retentionJob
+	}
+	if len(cfg.Publish.Backend) > 0 {
+		publisher, err := publish.NewFromConfig(store, cfg)
+		if err != nil {
+			return nil, err
+		}
+		srv.publisher = publisher
+	}
+	if err := srv.setupGateway(); err != nil {
+		return nil, err
+	}
 
 	return srv, nil
 }
 
-func (srv *Server) Shutdown() error {
+// Stops serving: health checks fail for the drain delay, then listeners are closed and
+// in-flight calls, including downloads, may finish until the context expires, after which
+// they are cut off. The store stays open; Close it once nothing else uses it
+func (srv *Server) Shutdown(ctx context.Context) {
 	log := logging.FromContext(srv.ctx)
 	log.Info("Shutting down server")
+	srv.drainHealth(ctx)
+	srv.backgroundLock.Lock()
+	srv.cancel()
+	srv.backgroundLock.Unlock()
 
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		gracefulStop(ctx, srv.grpcServer)
+	}()
+	go func() {
+		defer wg.Done()
+		if err := srv.httpServer.Shutdown(ctx); err != nil {
+			log.Errorf("Could not finish HTTP requests in time: %s", err)
+			srv.httpServer.Close()
+		}
+		// the gateway forwards HTTP requests, so it stops after them
+		gracefulStop(ctx, srv.gatewayServer)
+		srv.gatewayConn.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		gracefulStop(ctx, srv.adminServer)
+	}()
+	wg.Wait()
+
+	background := make(chan struct{})
+	go func() {
+		srv.background.Wait()
+		close(background)
+	}()
+	select {
+	case <-background:
+	case <-ctx.Done():
+		log.Error("Background work did not stop in time")
+	}
+	log.Info("Server stopped")
+}
+
+// Closes the store. Call Shutdown first
+func (srv *Server) Close() {
 	srv.db.Close()
-	return nil
 }
 
-func (srv *Server) Done() <-chan struct{} {
-	return srv.ctx.Done()
+// registers background work, which must call background.Done when it stops. Returns
+// false if the server is already shutting down
+func (srv *Server) startBackground() bool {
+	srv.backgroundLock.Lock()
+	defer srv.backgroundLock.Unlock()
+	if srv.ctx.Err() != nil {
+		return false
+	}
+	srv.background.Add(1)
+	return true
+}
+
+// stops the server once its in-flight calls and streams have finished, or forcibly once
+// the context expires
+func gracefulStop(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+		<-stopped
+	}
 }
 
 func (srv *Server) ServeGRPC() error {
@@ -98,79 +347,222 @@ func (srv *Server) ServeGRPC() error {
 	if err != nil {
 		return fmt.Errorf("Could not listen on %s: %w", srv.grpcAddress, err)
 	}
-	log.Infof("Serving GRPC on %s", srv.grpcAddress)
+	log.Infof("Serving GRPC on %s (TLS: %t)", srv.grpcAddress, srv.tls != nil)
 	return srv.grpcServer.Serve(lis)
 }
 
-func (srv *Server) ServeHTTP() error {
+// Serves the operator-only Admin service on its own listener. Returns immediately
+// if no admin port is configured
+func (srv *Server) ServeAdmin() error {
 	log := logging.FromContext(srv.ctx)
-	mux := runtime.NewServeMux()
-	opts := []grpc.DialOption{grpc.WithInsecure()}
-	err := proto.RegisterDiagnosisDBHandlerFromEndpoint(srv.ctx, mux, srv.grpcAddress, opts)
+	if len(srv.adminAddress) == 0 {
+		log.Info("No admin port configured; not serving Admin service")
+		return nil
+	} else if srv.admin == nil {
+		log.Info("Store does not support administration; not serving Admin service")
+		return nil
+	}
+	lis, err := net.Listen("tcp", srv.adminAddress)
+	if err != nil {
+		return fmt.Errorf("Could not listen on %s: %w", srv.adminAddress, err)
+	}
+	log.Infof("Serving Admin GRPC on %s", srv.adminAddress)
+	return srv.adminServer.Serve(lis)
+}
+
+// Periodically purges expired data from the store until the server is shut down.
+// Returns immediately if the store has no retention support
+func (srv *Server) RunRetention() error {
+	if srv.retention == nil {
+		logging.FromContext(srv.ctx).Info("Store does not support retention; not purging expired data")
+		return nil
+	}
+	if !srv.startBackground() {
+		return nil
+	}
+	defer srv.background.Done()
+	if err := srv.retention.Run(srv.ctx); err != context.Canceled {
+		return err
+	}
+	return nil
+}
+
+// Periodically publishes new keys to the blob store until the server is shut down. Returns
+// immediately if publishing is not configured
+func (srv *Server) RunPublisher() error {
+	if srv.publisher == nil || !srv.startBackground() {
+		return nil
+	}
+	defer srv.background.Done()
+	if err := srv.publisher.Run(srv.ctx); err != context.Canceled {
+		return err
+	}
+	return nil
+}
+
+// the routes of the HTTP gateway declared in proto/commons.proto. Metrics label requests
+// to any other path as "other"
+var gatewayRoutes = []string{
+	"/v1/diagnosis/add_report",
+	"/v1/diagnosis/get_diagnosis_keys",
+	"/v1/diagnosis/get_authorization_token",
+	"/v1/diagnosis/exchange_verification_code",
+}
+
+// connects the HTTP gateway to its own in-process GRPC server rather than to the public
+// listener, so it needs no client certificate of its own and can pass on the identity of
+// HTTP clients. The connection is only established once the gateway is served
+func (srv *Server) setupGateway() error {
+	conn, err := grpc.DialContext(srv.ctx, "gateway",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return srv.gatewayListener.Dial(ctx)
+		}),
+		grpc.WithInsecure())
 	if err != nil {
+		return fmt.Errorf("Could not connect gateway: %w", err)
+	}
+
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(gatewayHeaderMatcher),
+		runtime.WithMetadata(gatewayMetadata),
+		runtime.WithOutgoingHeaderMatcher(gatewayOutgoingHeaderMatcher),
+	)
+	if err := proto.RegisterDiagnosisDBHandler(srv.ctx, mux, conn); err != nil {
+		conn.Close()
 		return err
 	}
 
-	log.Infof("Serving HTTP on %s", srv.httpAddress)
-	return http.ListenAndServe(srv.httpAddress, mux)
+	handler := http.NewServeMux()
+	handler.HandleFunc("/healthz", srv.healthz)
+	handler.HandleFunc("/readyz", srv.readyz)
+	if srv.metricsOnHTTP {
+		handler.Handle("/metrics", metrics.Handler())
+	}
+	for _, route := range gatewayRoutes {
+		handler.Handle(route, metrics.InstrumentHandler(route, mux))
+	}
+	handler.Handle("/", metrics.InstrumentHandler("other", mux))
+
+	srv.gatewayConn = conn
+	srv.httpServer = &http.Server{
+		Addr:    srv.httpAddress,
+		Handler: handler,
+	}
+	if srv.tls != nil {
+		srv.httpServer.TLSConfig = srv.tls.TLSConfig()
+	}
+	return nil
+}
+
+// Serves the HTTP gateway until the server is shut down
+func (srv *Server) ServeHTTP() error {
+	log := logging.FromContext(srv.ctx)
+	go func() {
+		if err := srv.gatewayServer.Serve(srv.gatewayListener); err != nil {
+			log.Errorf("Gateway GRPC server stopped: %s", err)
+		}
+	}()
+
+	log.Infof("Serving HTTP on %s (TLS: %t)", srv.httpAddress, srv.tls != nil)
+	var err error
+	if srv.tls != nil {
+		err = srv.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = srv.httpServer.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 func (srv *Server) AddReport(ctx context.Context, report *proto.Report) (*proto.AddReportResponse, error) {
 	ctx = logging.WithLogger(ctx)
-	err := srv.db.AddReport(ctx, report)
+	err := srv.keys.AddReport(ctx, report)
 	if err != nil {
-		return &proto.AddReportResponse{
-			Error: err.Error(),
-		}, nil
+		return nil, statusError(ctx, err, &proto.AddReportResponse{Error: err.Error()})
 	}
 	return &proto.AddReportResponse{}, nil
 }
 
 func (srv *Server) GetDiagnosisKeys(req *proto.GetKeyRequest, client proto.DiagnosisDB_GetDiagnosisKeysServer) error {
-	ctx := logging.WithLogger(srv.ctx)
+	// the client's context, so that downloads in progress can finish on shutdown
+	ctx := logging.WithLogger(client.Context())
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
 	defer cancel()
 
-	results, errchan := srv.db.GetDiagnosisKeys(ctx, req)
+	results, errchan := srv.keys.GetDiagnosisKeys(ctx, req)
+	// tells the time spent waiting for the store apart from the time spent sending
+	_, span := tracing.StartSpan(ctx, "GetDiagnosisKeys.Send")
+	var (
+		messages int
+		sending  time.Duration
+	)
+	defer func() {
+		span.SetAttribute("messages", messages)
+		span.SetAttribute("send_seconds", sending.Seconds())
+		span.End()
+	}()
 	for {
 		select {
 		case err := <-errchan:
 			if err == nil {
 				return nil
 			}
-			serr := client.Send(&proto.GetDiagnosisKeyResponse{
-				Error: err.Error(),
-			})
-			if serr != nil {
-				return fmt.Errorf("Could not send error (%w): %w", err, serr)
+			span.SetError(err)
+			// clients that predate status codes read the error field of the last message
+			if serr := client.Send(&proto.GetDiagnosisKeyResponse{Error: err.Error()}); serr != nil {
+				logging.FromContext(ctx).Debugf("Could not send error: %s", serr)
 			}
-		case tstek := <-results:
-			if tstek == nil {
+			return statusError(ctx, err, nil)
+		case resp := <-results:
+			if resp == nil {
 				return nil
 			}
-			serr := client.Send(&proto.GetDiagnosisKeyResponse{
-				Record: tstek,
-			})
+			start := time.Now()
+			serr := client.Send(resp)
+			sending += time.Since(start)
 			if serr != nil {
+				span.SetError(serr)
 				return fmt.Errorf("Could not send: %w", serr)
 			}
+			messages++
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
-	return nil
 }
 
 func (srv *Server) GetAuthorizationToken(ctx context.Context, req *proto.TokenRequest) (*proto.TokenResponse, error) {
 	ctx = logging.WithLogger(ctx)
+	// the authority may authenticate with an Authorization header or a client certificate
+	// instead of the api_key field
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && len(req.ApiKey) == 0 {
+		req.ApiKey = principal.ApiKey
+	}
 	one_time_auth_key, err := srv.db.CreateAuthorizationKey(ctx, req)
 	if err != nil {
-		return &proto.TokenResponse{
-			Error: err.Error(),
-		}, nil
+		return nil, statusError(ctx, err, &proto.TokenResponse{Error: err.Error()})
 	}
 
-	return &proto.TokenResponse{
-		AuthorizationKey: one_time_auth_key,
+	resp := &proto.TokenResponse{
+		AuthorizationKey:          one_time_auth_key.Key,
+		AuthorizationKeyExpiresAt: one_time_auth_key.ExpiresAt.Format(time.RFC3339),
+	}
+	if len(one_time_auth_key.VerificationCode) > 0 {
+		resp.VerificationCode = one_time_auth_key.VerificationCode
+		resp.VerificationCodeExpiresAt = one_time_auth_key.VerificationCodeExpiresAt.Format(time.RFC3339)
+	}
+	return resp, nil
+}
+
+func (srv *Server) ExchangeVerificationCode(ctx context.Context, req *proto.VerificationCodeRequest) (*proto.VerificationCodeResponse, error) {
+	ctx = logging.WithLogger(ctx)
+	authorization_key, err := srv.db.ExchangeVerificationCode(ctx, req)
+	if err != nil {
+		return nil, statusError(ctx, err, &proto.VerificationCodeResponse{Error: err.Error()})
+	}
+	return &proto.VerificationCodeResponse{
+		AuthorizationKey: authorization_key,
 	}, nil
 }
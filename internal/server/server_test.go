@@ -0,0 +1,343 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/database"
+	"github.com/covista/commons/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testAdminToken = "testadmintoken0123456789"
+
+type testServer struct {
+	client proto.DiagnosisDBClient
+	admin  proto.AdminClient
+	// serves the HTTP gateway
+	http  *httptest.Server
+	store *database.MemoryStore
+	// of the store's only health authority
+	apiKey []byte
+}
+
+// serves a Server backed by a MemoryStore over in-memory connections
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	cfg := &config.Config{
+		GRPC:     config.GRPC{ListenAddress: "localhost", Port: "5000"},
+		HTTP:     config.HTTP{ListenAddress: "localhost", Port: "5001"},
+		Admin:    config.Admin{Port: "5002", Token: testAdminToken},
+		Database: config.Database{Backend: "memory"},
+	}
+	store, err := database.NewMemoryStore(cfg)
+	if err != nil {
+		t.Fatalf("could not create store: %v", err)
+	}
+	_, api_key, err := store.CreateAuthority(context.Background(), &proto.CreateAuthorityRequest{Name: "Test Health Authority"})
+	if err != nil {
+		t.Fatalf("could not create authority: %v", err)
+	}
+	srv, err := New(context.Background(), cfg, store)
+	if err != nil {
+		t.Fatalf("could not create server: %v", err)
+	}
+
+	dial := func(server *grpc.Server) *grpc.ClientConn {
+		listener := bufconn.Listen(1 << 20)
+		go server.Serve(listener)
+		conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}))
+		if err != nil {
+			t.Fatalf("could not dial server: %v", err)
+		}
+		t.Cleanup(func() {
+			conn.Close()
+			server.Stop()
+		})
+		return conn
+	}
+	if err := srv.setupGateway(); err != nil {
+		t.Fatalf("could not set up gateway: %v", err)
+	}
+	go srv.gatewayServer.Serve(srv.gatewayListener)
+	gateway := httptest.NewServer(srv.httpServer.Handler)
+	t.Cleanup(func() {
+		gateway.Close()
+		srv.gatewayConn.Close()
+		srv.gatewayServer.Stop()
+	})
+	return &testServer{
+		client: proto.NewDiagnosisDBClient(dial(srv.grpcServer)),
+		admin:  proto.NewAdminClient(dial(srv.adminServer)),
+		http:   gateway,
+		store:  store,
+		apiKey: api_key,
+	}
+}
+
+func withBearer(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// checks the status code of err and, for InvalidArgument, the field of its BadRequest
+// detail. Returns the status
+func checkStatus(t *testing.T, err error, code codes.Code, field string) *status.Status {
+	t.Helper()
+	st := status.Convert(err)
+	if st.Code() != code {
+		t.Fatalf("expected %s, got %s: %s", code, st.Code(), st.Message())
+	}
+	if code == codes.InvalidArgument {
+		found := false
+		for _, detail := range st.Details() {
+			if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+				found = len(badRequest.FieldViolations) == 1 && badRequest.FieldViolations[0].Field == field
+			}
+		}
+		if !found {
+			t.Fatalf("expected a BadRequest detail for %q, got %v", field, st.Details())
+		}
+	}
+	return st
+}
+
+func TestGetAuthorizationToken(t *testing.T) {
+	ts := newTestServer(t)
+	now := time.Now().UTC()
+	request := func() *proto.TokenRequest {
+		return &proto.TokenRequest{
+			PermittedRangeStart:    now.Add(-14 * 24 * time.Hour).Format(time.RFC3339),
+			PermittedRangeEnd:      now.Format(time.RFC3339),
+			VerificationCodeLength: 8,
+		}
+	}
+	for _, tt := range []struct {
+		name    string
+		bearer  string
+		request func() *proto.TokenRequest
+		code    codes.Code
+		field   string
+	}{
+		{"authenticated", hex.EncodeToString(ts.apiKey), request, codes.OK, ""},
+		{"api_key field", "", func() *proto.TokenRequest { r := request(); r.ApiKey = ts.apiKey; return r }, codes.OK, ""},
+		{"unauthenticated", "", request, codes.Unauthenticated, ""},
+		{"unknown api_key", hex.EncodeToString(make([]byte, 16)), request, codes.Unauthenticated, ""},
+		{"malformed header", "not hex", request, codes.Unauthenticated, ""},
+		{"mismatched api_key field", hex.EncodeToString(ts.apiKey), func() *proto.TokenRequest { r := request(); r.ApiKey = make([]byte, 16); return r }, codes.Unauthenticated, ""},
+		{"invalid range", hex.EncodeToString(ts.apiKey), func() *proto.TokenRequest { r := request(); r.PermittedRangeEnd = "yesterday"; return r }, codes.InvalidArgument, "permitted_range_end"},
+		{"invalid code length", hex.EncodeToString(ts.apiKey), func() *proto.TokenRequest { r := request(); r.VerificationCodeLength = 2; return r }, codes.InvalidArgument, "verification_code_length"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.bearer) > 0 {
+				ctx = withBearer(ctx, tt.bearer)
+			}
+			resp, err := ts.client.GetAuthorizationToken(ctx, tt.request())
+			checkStatus(t, err, tt.code, tt.field)
+			if tt.code == codes.OK && (len(resp.AuthorizationKey) != 16 || len(resp.VerificationCode) != 8) {
+				t.Fatalf("unexpected response %v", resp)
+			}
+		})
+	}
+}
+
+// clients that predate status codes find the response they used to get, with its error
+// field set, among the status details
+func TestLegacyErrorDetail(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	// returns the error field of the legacy response among the details
+	legacyError := func(st *status.Status) string {
+		for _, detail := range st.Details() {
+			if legacy, ok := detail.(interface{ GetError() string }); ok {
+				return legacy.GetError()
+			}
+		}
+		t.Fatalf("no legacy response in %v", st.Details())
+		return ""
+	}
+	for _, tt := range []struct {
+		name string
+		call func() error
+		code codes.Code
+	}{
+		{"AddReport", func() error {
+			_, err := ts.client.AddReport(ctx, &proto.Report{AuthorizationKey: make([]byte, 16)})
+			return err
+		}, codes.InvalidArgument},
+		{"GetAuthorizationToken", func() error {
+			_, err := ts.client.GetAuthorizationToken(withBearer(ctx, hex.EncodeToString(ts.apiKey)), &proto.TokenRequest{})
+			return err
+		}, codes.InvalidArgument},
+		{"ExchangeVerificationCode", func() error {
+			_, err := ts.client.ExchangeVerificationCode(ctx, &proto.VerificationCodeRequest{VerificationCode: "00000000"})
+			return err
+		}, codes.NotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(tt.call())
+			if st.Code() != tt.code {
+				t.Fatalf("expected %s, got %s: %s", tt.code, st.Code(), st.Message())
+			} else if legacy := legacyError(st); len(legacy) == 0 || legacy != st.Message() {
+				t.Fatalf("legacy error %q does not match status message %q", legacy, st.Message())
+			}
+		})
+	}
+}
+
+func TestReportAndDownload(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	token, err := ts.client.GetAuthorizationToken(withBearer(ctx, hex.EncodeToString(ts.apiKey)), &proto.TokenRequest{
+		PermittedRangeStart:    now.Add(-14 * 24 * time.Hour).Format(time.RFC3339),
+		PermittedRangeEnd:      now.Format(time.RFC3339),
+		VerificationCodeLength: 8,
+	})
+	if err != nil {
+		t.Fatalf("could not get token: %v", err)
+	}
+	wrongCode := "00000000"
+	if token.VerificationCode == wrongCode {
+		wrongCode = "11111111"
+	}
+	report := func(authorization_key []byte) *proto.Report {
+		return &proto.Report{
+			AuthorizationKey: authorization_key,
+			Reports:          []*proto.TimestampedTEK{{TEK: make([]byte, 16), ENIN: uint32(now.Add(-24*time.Hour).Unix() / 600), RollingPeriod: 144}},
+		}
+	}
+
+	exchange := func(code string) (*proto.VerificationCodeResponse, error) {
+		return ts.client.ExchangeVerificationCode(ctx, &proto.VerificationCodeRequest{VerificationCode: code})
+	}
+	_, err = exchange(wrongCode)
+	checkStatus(t, err, codes.NotFound, "")
+	exchanged, err := exchange(token.VerificationCode)
+	checkStatus(t, err, codes.OK, "")
+	_, err = exchange(token.VerificationCode)
+	checkStatus(t, err, codes.NotFound, "")
+
+	_, err = ts.client.AddReport(ctx, report(make([]byte, 16)))
+	checkStatus(t, err, codes.NotFound, "")
+	_, err = ts.client.AddReport(ctx, report(exchanged.AuthorizationKey))
+	checkStatus(t, err, codes.OK, "")
+	_, err = ts.client.AddReport(ctx, report(exchanged.AuthorizationKey))
+	checkStatus(t, err, codes.FailedPrecondition, "")
+
+	// returns the keys downloaded and how many messages carried the legacy error field
+	download := func(request *proto.GetKeyRequest, code codes.Code) (keys, legacyErrors int) {
+		t.Helper()
+		stream, err := ts.client.GetDiagnosisKeys(ctx, request)
+		if err != nil {
+			t.Fatalf("could not start download: %v", err)
+		}
+		for {
+			resp, err := stream.Recv()
+			if err == io.EOF {
+				checkStatus(t, nil, code, "")
+				return
+			} else if err != nil {
+				checkStatus(t, err, code, "")
+				return
+			}
+			if resp.Record != nil {
+				keys++
+			} else if len(resp.Error) > 0 {
+				legacyErrors++
+			}
+		}
+	}
+	if keys, legacyErrors := download(&proto.GetKeyRequest{Hrange: &proto.HistoricalRange{Days: 14}}, codes.OK); keys != 1 || legacyErrors != 0 {
+		t.Fatalf("expected 1 key and no errors, got %d keys and %d errors", keys, legacyErrors)
+	}
+	// clients that predate status codes read the error from the last message
+	if keys, legacyErrors := download(&proto.GetKeyRequest{}, codes.InvalidArgument); keys != 0 || legacyErrors != 1 {
+		t.Fatalf("expected only a legacy error message, got %d keys and %d errors", keys, legacyErrors)
+	}
+}
+
+func TestAdminToken(t *testing.T) {
+	ts := newTestServer(t)
+	for _, tt := range []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{"no token", "", codes.Unauthenticated},
+		{"wrong token", "wrongadmintoken0123456789", codes.Unauthenticated},
+		{"operator token", testAdminToken, codes.OK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if len(tt.token) > 0 {
+				ctx = withBearer(ctx, tt.token)
+			}
+			_, err := ts.admin.ListAuthorities(ctx, &proto.ListAuthoritiesRequest{})
+			checkStatus(t, err, tt.code, "")
+		})
+	}
+}
+
+// posts the JSON body to a route of the HTTP gateway, and decodes the JSON response
+func (ts *testServer) post(t *testing.T, route, bearer, body string) (int, map[string]interface{}) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, ts.http.URL+route, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("could not create request: %v", err)
+	}
+	if len(bearer) > 0 {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := ts.http.Client().Do(req)
+	if err != nil {
+		t.Fatalf("could not post to %s: %v", route, err)
+	}
+	defer resp.Body.Close()
+	var decoded map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatalf("could not decode response of %s: %v", route, err)
+	}
+	return resp.StatusCode, decoded
+}
+
+func TestGateway(t *testing.T) {
+	ts := newTestServer(t)
+	now := time.Now().UTC()
+	body := `{"permitted_range_start": "` + now.Add(-24*time.Hour).Format(time.RFC3339) + `", "permitted_range_end": "` + now.Format(time.RFC3339) + `"}`
+
+	code, resp := ts.post(t, "/v1/diagnosis/get_authorization_token", hex.EncodeToString(ts.apiKey), body)
+	if code != http.StatusOK || resp["authorization_key"] == nil {
+		t.Fatalf("expected an authorization key, got %d: %v", code, resp)
+	}
+	if code, _ := ts.post(t, "/v1/diagnosis/get_authorization_token", "", body); code != http.StatusUnauthorized {
+		t.Fatalf("expected %d without credentials, got %d", http.StatusUnauthorized, code)
+	}
+}
+
+// clients of the HTTP gateway that predate status codes read the error field of the body
+func TestGatewayLegacyError(t *testing.T) {
+	ts := newTestServer(t)
+	code, resp := ts.post(t, "/v1/diagnosis/add_report", "", `{"authorization_key": "AAAAAAAAAAAAAAAAAAAAAA=="}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected %d, got %d: %v", http.StatusBadRequest, code, resp)
+	} else if legacy, _ := resp["error"].(string); len(legacy) == 0 || legacy != resp["message"] {
+		t.Fatalf("expected the error field to hold the message, got %v", resp)
+	}
+}
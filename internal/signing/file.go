@@ -0,0 +1,98 @@
+package signing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/covista/commons/internal/config"
+)
+
+// authority_ids are UUIDs
+const authorityIDLength = 16
+
+func checkConfig(cfg *config.Config) error {
+	return config.Check(cfg, config.ExportSection)
+}
+
+type authoritySigner struct {
+	authorityID []byte
+	signer      Signer
+}
+
+// FileKeyProvider serves signers backed by PEM-encoded private keys on disk
+type FileKeyProvider struct {
+	signers []authoritySigner
+}
+
+// Creates a new FileKeyProvider, loading every private key listed in the configuration
+func NewFileKeyProviderFromConfig(cfg *config.Config) (*FileKeyProvider, error) {
+	if err := checkConfig(cfg); err != nil {
+		return nil, fmt.Errorf("Invalid config for signing keys: %w", err)
+	}
+	provider := &FileKeyProvider{}
+	for _, key := range cfg.Signing.Keys {
+		authorityID, err := hex.DecodeString(key.AuthorityID)
+		if err != nil || len(authorityID) != authorityIDLength {
+			// signing with a truncated authority_id would publish exports no client accepts
+			return nil, fmt.Errorf("Invalid config for signing keys: AuthorityID %q of %s is not a hex-encoded %d-byte authority_id", key.AuthorityID, key.PrivateKeyFile, authorityIDLength)
+		}
+		priv, err := loadPrivateKey(key.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("Could not load signing key %s: %w", key.KeyID, err)
+		}
+		signer, err := NewECDSASigner(priv, key.KeyID, key.KeyVersion)
+		if err != nil {
+			return nil, err
+		}
+		provider.signers = append(provider.signers, authoritySigner{
+			authorityID: authorityID,
+			signer:      signer,
+		})
+	}
+	return provider, nil
+}
+
+// Returns all signers configured for the given health authority, in configuration order
+func (p *FileKeyProvider) Signers(authorityID []byte) ([]Signer, error) {
+	var signers []Signer
+	for _, as := range p.signers {
+		if bytes.Equal(as.authorityID, authorityID) {
+			signers = append(signers, as.signer)
+		}
+	}
+	return signers, nil
+}
+
+// reads an ECDSA private key from a PEM file in either SEC 1 ("EC PRIVATE KEY")
+// or PKCS #8 ("PRIVATE KEY") form
+func loadPrivateKey(filename string) (*ecdsa.PrivateKey, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM block", filename)
+	}
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ecKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s does not contain an ECDSA private key", filename)
+		}
+		return ecKey, nil
+	default:
+		return nil, fmt.Errorf("%s contains unsupported PEM block %q", filename, block.Type)
+	}
+}
@@ -0,0 +1,75 @@
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/covista/commons/internal/config"
+)
+
+const testAuthorityID = "da250d7fbffca634bf9b38e9430508bb"
+
+// writes a new SEC 1 private key to a file and returns its name
+func writeTestKey(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("could not marshal key: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "signing")
+	if err != nil {
+		t.Fatalf("could not create directory: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	filename := filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(filename, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("could not write key: %v", err)
+	}
+	return filename
+}
+
+func TestNewFileKeyProviderFromConfig(t *testing.T) {
+	filename := writeTestKey(t)
+	for _, tt := range []struct {
+		name        string
+		authorityID string
+		valid       bool
+	}{
+		{"valid", testAuthorityID, true},
+		{"not hex", "da250d7fbffca634bf9b38e9430508bz", false},
+		{"truncated", testAuthorityID[:30], false},
+		{"odd length", testAuthorityID[:31], false},
+		{"empty", "", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Signing.Keys = []config.SigningKey{{AuthorityID: tt.authorityID, KeyID: "310", KeyVersion: "v1", PrivateKeyFile: filename}}
+			provider, err := NewFileKeyProviderFromConfig(cfg)
+			if !tt.valid {
+				if err == nil || !strings.Contains(err.Error(), filename) {
+					t.Fatalf("expected an error naming %s, got %v", filename, err)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("could not load signing keys: %v", err)
+			}
+			authorityID, _ := hex.DecodeString(tt.authorityID)
+			if signers, _ := provider.Signers(authorityID); len(signers) != 1 {
+				t.Fatalf("expected 1 signer, got %d", len(signers))
+			}
+		})
+	}
+}
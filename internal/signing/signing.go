@@ -0,0 +1,71 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/covista/commons/proto/export"
+	pb "github.com/golang/protobuf/proto"
+)
+
+// ASN.1 OID of ECDSA with SHA-256, as expected by the Exposure Notification API
+const AlgorithmECDSAP256SHA256 = "1.2.840.10045.4.3.2"
+
+// Signer produces signatures over export files
+type Signer interface {
+	// metadata identifying the public key that verifies this signer's signatures
+	SignatureInfo() *export.SignatureInfo
+	// signs the given export.bin contents
+	Sign(data []byte) ([]byte, error)
+}
+
+// KeyProvider resolves which signers are active for a health authority. Several signers
+// may be active at once so that a new key can be rolled out before the old one is retired.
+type KeyProvider interface {
+	Signers(authorityID []byte) ([]Signer, error)
+}
+
+// ecdsaSigner signs with an ECDSA P-256 private key
+type ecdsaSigner struct {
+	key     *ecdsa.PrivateKey
+	keyID   string
+	version string
+}
+
+// Creates a new Signer from a P-256 private key. keyID and version are published in the
+// SignatureInfo so that clients can find the matching public key
+func NewECDSASigner(key *ecdsa.PrivateKey, keyID, version string) (Signer, error) {
+	if key == nil {
+		return nil, errors.New("Private key is nil")
+	} else if key.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("Private key for %s uses curve %s, not P-256", keyID, key.Curve.Params().Name)
+	}
+	return &ecdsaSigner{
+		key:     key,
+		keyID:   keyID,
+		version: version,
+	}, nil
+}
+
+func (s *ecdsaSigner) SignatureInfo() *export.SignatureInfo {
+	return &export.SignatureInfo{
+		VerificationKeyId:      pb.String(s.keyID),
+		VerificationKeyVersion: pb.String(s.version),
+		SignatureAlgorithm:     pb.String(AlgorithmECDSAP256SHA256),
+	}
+}
+
+// returns the signature in X9.62 (ASN.1 DER) format
+func (s *ecdsaSigner) Sign(data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	sig, err := s.key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("Could not sign with key %s: %w", s.keyID, err)
+	}
+	return sig, nil
+}
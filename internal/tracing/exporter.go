@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// the standard output exporter writes here
+var stdout io.Writer = os.Stdout
+
+// Exporter receives every finished span of a sampled trace
+type Exporter interface {
+	Export(span *SpanData) error
+	// flushes and releases the exporter; no spans are exported afterwards
+	Close() error
+}
+
+// SpanData is a finished span, as handed to exporters
+type SpanData struct {
+	Name     string    `json:"name"`
+	TraceID  string    `json:"trace_id"`
+	SpanID   string    `json:"span_id"`
+	ParentID string    `json:"parent_id,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	// seconds
+	Duration   float64                `json:"duration"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+// writes spans as JSON, one per line
+type writerExporter struct {
+	sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// Returns an exporter writing spans to w as JSON, one per line
+func NewWriterExporter(w io.Writer) Exporter {
+	return &writerExporter{encoder: json.NewEncoder(w)}
+}
+
+// Returns an exporter appending spans to the file as JSON, one per line
+func NewFileExporter(path string) (Exporter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("Could not open trace file: %w", err)
+	}
+	return &writerExporter{encoder: json.NewEncoder(f), closer: f}, nil
+}
+
+func (e *writerExporter) Export(span *SpanData) error {
+	e.Lock()
+	defer e.Unlock()
+	if e.encoder == nil {
+		return nil
+	}
+	return e.encoder.Encode(span)
+}
+
+func (e *writerExporter) Close() error {
+	e.Lock()
+	defer e.Unlock()
+	e.encoder = nil
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
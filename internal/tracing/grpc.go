@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// the metadata carrying the W3C trace context; the HTTP gateway forwards the header of the
+// same name
+const TraceparentKey = "traceparent"
+
+// wraps a server stream to replace its context
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
+
+// continues the trace of the client's traceparent, if it sent a valid one
+func withIncomingParent(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(TraceparentKey); len(values) == 1 {
+		if parent, ok := ParseTraceparent(values[0]); ok {
+			return ContextWithRemoteParent(ctx, parent)
+		}
+	}
+	return ctx
+}
+
+// Returns interceptors that run every call in a span named after its method, continuing
+// the client's trace
+func Interceptors() []grpc.ServerOption {
+	finish := func(span *Span, err error) {
+		span.SetAttribute("code", status.Code(err).String())
+		span.SetError(err)
+		span.End()
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			ctx, span := StartSpan(withIncomingParent(ctx), info.FullMethod)
+			resp, err := handler(ctx, req)
+			finish(span, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, span := StartSpan(withIncomingParent(ss.Context()), info.FullMethod)
+			err := handler(srv, &tracedStream{ss, ctx})
+			finish(span, err)
+			return err
+		}),
+	}
+}
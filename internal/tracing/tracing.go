@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/covista/commons/internal/config"
+	"github.com/covista/commons/internal/logging"
+)
+
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext identifies a span across process boundaries
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// spans of unsampled traces are propagated but not exported
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Formats the span context as a W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// Parses a W3C traceparent header. Later versions are read as far as version 00 goes
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || value != strings.ToLower(value) {
+		return sc, false
+	}
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || len(version) != 1 || version[0] == 0xff || (version[0] == 0 && len(parts) != 4) {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 || len(parts[1]) != 2*len(sc.TraceID) || len(parts[2]) != 2*len(sc.SpanID) {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+var (
+	exporterLock sync.RWMutex
+	exporter     Exporter
+	sampleRatio  = 1.0
+)
+
+func checkConfig(cfg *config.Config) error {
+	return config.Check(cfg, config.TracingSection)
+}
+
+// Sets up the configured exporter. Tracing stays disabled if none is configured
+func Configure(cfg *config.Config) error {
+	if err := checkConfig(cfg); err != nil {
+		return fmt.Errorf("Invalid config for tracing: %w", err)
+	}
+	var e Exporter
+	switch cfg.Tracing.Exporter {
+	case "stdout":
+		e = NewWriterExporter(stdout)
+	case "file":
+		var err error
+		if e, err = NewFileExporter(cfg.Tracing.File); err != nil {
+			return err
+		}
+	}
+	ratio := cfg.Tracing.SampleRatio
+	if ratio == 0 {
+		ratio = 1
+	}
+	exporterLock.Lock()
+	sampleRatio = ratio
+	exporterLock.Unlock()
+	return SetExporter(e)
+}
+
+// Replaces the exporter spans are sent to, closing the previous one. A nil exporter
+// disables tracing
+func SetExporter(e Exporter) error {
+	exporterLock.Lock()
+	previous := exporter
+	exporter = e
+	exporterLock.Unlock()
+	if previous != nil {
+		return previous.Close()
+	}
+	return nil
+}
+
+// Closes the exporter, flushing the spans it holds, and disables tracing
+func Close() error {
+	return SetExporter(nil)
+}
+
+func current() (Exporter, float64) {
+	exporterLock.RLock()
+	defer exporterLock.RUnlock()
+	return exporter, sampleRatio
+}
+
+// Span times an operation. All methods are safe to call on a nil Span, which is what
+// StartSpan returns while tracing is disabled
+type Span struct {
+	lock       sync.Mutex
+	name       string
+	context    SpanContext
+	parent     SpanID
+	start      time.Time
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+type spanKey struct{}
+type remoteParentKey struct{}
+
+// Starts a span as a child of the context's span or remote parent, or as the root of a new
+// trace. The returned context carries the new span
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	e, ratio := current()
+	if e == nil {
+		return ctx, nil
+	}
+	span := &Span{name: name, start: time.Now()}
+	if parent, ok := SpanContextFromContext(ctx); ok {
+		span.context.TraceID = parent.TraceID
+		span.context.Sampled = parent.Sampled
+		span.parent = parent.SpanID
+	} else {
+		rand.Read(span.context.TraceID[:])
+		span.context.Sampled = ratio >= 1 || mathrand.Float64() < ratio
+	}
+	rand.Read(span.context.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Returns a context carrying the span as its current span, e.g. to start children of a span
+// from a context that does not carry it
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Returns a context whose spans continue a trace from another process
+func ContextWithRemoteParent(ctx context.Context, parent SpanContext) context.Context {
+	return context.WithValue(ctx, remoteParentKey{}, parent)
+}
+
+// Returns the context's current span, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Returns the span context of the context's current span, or else of its remote parent
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.context, true
+	}
+	parent, ok := ctx.Value(remoteParentKey{}).(SpanContext)
+	return parent, ok && parent.IsValid()
+}
+
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// Attaches a value to the span, e.g. a row count
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]interface{})
+	}
+	s.attributes[key] = value
+}
+
+// Marks the operation as failed. A nil error is ignored
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.lock.Lock()
+	s.err = err
+	s.lock.Unlock()
+}
+
+// Ends the span and exports it if its trace is sampled. Only the first call has an effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	data := &SpanData{
+		Name:       s.name,
+		TraceID:    s.context.TraceID.String(),
+		SpanID:     s.context.SpanID.String(),
+		Start:      s.start,
+		End:        end,
+		Duration:   end.Sub(s.start).Seconds(),
+		Attributes: s.attributes,
+	}
+	if s.parent != (SpanID{}) {
+		data.ParentID = s.parent.String()
+	}
+	if s.err != nil {
+		data.Error = s.err.Error()
+	}
+	s.lock.Unlock()
+
+	e, _ := current()
+	if e == nil || !s.context.Sampled {
+		return
+	}
+	if err := e.Export(data); err != nil {
+		logging.FromContext(context.Background()).Warnf("Could not export span %s: %s", s.name, err)
+	}
+}